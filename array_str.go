@@ -28,8 +28,8 @@ func opMap(apply Applier, params []interface{}, data interface{}) (res interface
 	}
 
 	mappedArr := []interface{}{}
-	for _, item := range arr {
-		mappedItem, err := apply(scopedLogic, item)
+	for i, item := range arr {
+		mappedItem, err := ApplyIter(apply, scopedLogic, item, i)
 		if err != nil {
 			return nil, err
 		}
@@ -60,8 +60,8 @@ func opFilter(apply Applier, params []interface{}, data interface{}) (res interf
 	}
 
 	filteredArr := []interface{}{}
-	for _, item := range arr {
-		r, err := apply(scopedLogic, item)
+	for i, item := range arr {
+		r, err := ApplyIter(apply, scopedLogic, item, i)
 		if err != nil {
 			return nil, err
 		}
@@ -94,11 +94,11 @@ func opReduce(apply Applier, params []interface{}, data interface{}) (res interf
 		return initial, nil
 	}
 
-	for _, item := range arr {
-		r, err := apply(scopedLogic, map[string]interface{}{
+	for i, item := range arr {
+		r, err := ApplyIter(apply, scopedLogic, map[string]interface{}{
 			"current":     item,
 			"accumulator": initial,
-		})
+		}, i)
 		if err != nil {
 			return nil, err
 		}
@@ -133,8 +133,8 @@ func opAll(apply Applier, params []interface{}, data interface{}) (res interface
 	if len(arr) == 0 {
 		return false, nil
 	}
-	for _, item := range arr {
-		r, err := apply(scopedLogic, item)
+	for i, item := range arr {
+		r, err := ApplyIter(apply, scopedLogic, item, i)
 		if err != nil {
 			return nil, err
 		}
@@ -170,8 +170,8 @@ func opNone(apply Applier, params []interface{}, data interface{}) (res interfac
 	if len(arr) == 0 {
 		return true, nil
 	}
-	for _, item := range arr {
-		r, err := apply(scopedLogic, item)
+	for i, item := range arr {
+		r, err := ApplyIter(apply, scopedLogic, item, i)
 		if err != nil {
 			return nil, err
 		}
@@ -207,8 +207,8 @@ func opSome(apply Applier, params []interface{}, data interface{}) (res interfac
 	if len(arr) == 0 {
 		return false, nil
 	}
-	for _, item := range arr {
-		r, err := apply(scopedLogic, item)
+	for i, item := range arr {
+		r, err := ApplyIter(apply, scopedLogic, item, i)
 		if err != nil {
 			return nil, err
 		}
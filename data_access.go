@@ -3,12 +3,13 @@ package jsonlogic
 import (
 	"fmt"
 	"strconv"
-	"strings"
 )
 
 // AddOpVar adds "var" operation to the JSONLogic instance. Param restriction:
 //   - At least one param (the key).
 //   - Keys must be evaluated to json primitives.
+//
+// Keys are in the syntax set by JSONLogic.SetPathSyntax, which is json-logic-js compatible DotPath by default.
 func AddOpVar(jl *JSONLogic) {
 	jl.AddOperation("var", opVar)
 }
@@ -22,17 +23,33 @@ func opVar(apply Applier, params []interface{}, data interface{}) (res interface
 		return
 	}
 
-	param0 := params[0]
 	var param1 interface{}
 	if len(params) >= 2 {
 		param1 = params[1]
 	}
 
-	var key string
-	switch k := param0.(type) {
+	res, found, err := getPath(pathSyntaxOf(apply), data, params[0])
+	if err != nil {
+		return nil, fmt.Errorf("var: %s", err.Error())
+	}
+	if !found {
+		return param1, nil
+	}
+	return res, nil
+
+}
+
+// getPath gets the value at path (a "var" key) of data according to syntax, found is false if the path
+// does not exist. See PathSyntax.
+func getPath(syntax PathSyntax, data, path interface{}) (res interface{}, found bool, err error) {
+	var (
+		key      string
+		isString bool
+	)
+	switch k := path.(type) {
 	case nil:
 		// Returns whole data if key is null
-		return data, nil
+		return data, true, nil
 	case bool:
 		if k {
 			key = "true"
@@ -44,35 +61,57 @@ func opVar(apply Applier, params []interface{}, data interface{}) (res interface
 	case string:
 		// Returns whole data if key is empty string
 		if k == "" {
-			return data, nil
+			return data, true, nil
 		}
 		key = k
+		isString = true
 	default:
-		return nil, fmt.Errorf("var: key must be json primitive but got %T", param0)
+		return nil, false, fmt.Errorf("key must be json primitive but got %T", path)
 	}
 
-	res = data
 	// NOTE: key is not empty here
-	for _, part := range strings.Split(key, ".") {
-		switch r := res.(type) {
-		case []interface{}:
-			i, err := strconv.Atoi(part)
-			if err == nil && i >= 0 && i < len(r) {
-				res = r[i]
-				continue
-			}
+	segs, err := parsePath(syntax, key, isString)
+	if err != nil {
+		return nil, false, err
+	}
+	res, found = walkPath(data, segs)
+	return res, found, nil
+}
 
-		case map[string]interface{}:
-			v, ok := r[part]
-			if ok {
-				res = v
-				continue
+// getSegment gets the value of a key of object or an index of array.
+func getSegment(obj, seg interface{}) (interface{}, bool) {
+	switch o := obj.(type) {
+	case []interface{}:
+		var i int
+		switch s := seg.(type) {
+		case float64:
+			if s != float64(int(s)) {
+				return nil, false
 			}
+			i = int(s)
+		case string:
+			var err error
+			i, err = strconv.Atoi(s)
+			if err != nil {
+				return nil, false
+			}
+		default:
+			return nil, false
 		}
-		return param1, nil
-	}
-	return res, nil
+		if i < 0 || i >= len(o) {
+			return nil, false
+		}
+		return o[i], true
 
+	case map[string]interface{}:
+		key, err := ToString(seg)
+		if err != nil {
+			return nil, false
+		}
+		v, ok := o[key]
+		return v, ok
+	}
+	return nil, false
 }
 
 // AddOpMissing adds "missing" operation to the JSONLogic instance.
@@ -152,3 +191,146 @@ func opMissingSome(apply Applier, params []interface{}, data interface{}) (res i
 	}
 
 }
+
+// AddOpExists adds "exists" operation to the JSONLogic instance. Param restriction:
+//   - At least one param.
+//   - Params must be evaluated to json primitives (keys, the same as "var").
+//
+// It returns true if all keys are present in data, regardless of their values. Unlike "missing",
+// null/"" values are considered as present, for example:
+//   logic: {"exists":"a"}
+//   data: {"a":null}
+//   result will be true
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpExists(jl *JSONLogic) {
+	jl.AddOperation("exists", opExists)
+}
+
+func opExists(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	if len(params) < 1 {
+		return nil, fmt.Errorf("exists: expect at least one param")
+	}
+	params, err = ApplyParams(apply, params, data)
+	if err != nil {
+		return
+	}
+
+	for _, param := range params {
+		_, found, err := getPath(pathSyntaxOf(apply), data, param)
+		if err != nil {
+			return nil, fmt.Errorf("exists: %s", err.Error())
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// AddOpCoalesce adds "coalesce" operation to the JSONLogic instance. It returns the first param which is
+// not evaluated to null, or null if there is no such one. Params after it are not evaluated.
+// NOTE: Unlike "var"'s default, a present key with null value is also skipped, for example:
+//   logic: {"coalesce":[{"var":"nickname"},{"var":"name"},"anonymous"]}
+//   data: {"nickname":null,"name":"Bob"}
+//   result will be "Bob"
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpCoalesce(jl *JSONLogic) {
+	jl.AddOperation("coalesce", opCoalesce)
+}
+
+func opCoalesce(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	for _, param := range params {
+		res, err = apply(param, data)
+		if err != nil {
+			return nil, err
+		}
+		if res != nil {
+			return res, nil
+		}
+	}
+	return nil, nil
+}
+
+// Emptiness modes of "default" operation.
+const (
+	// EmptyNull treats only null as empty.
+	EmptyNull = "null"
+	// EmptyMissing treats null/"" as empty, the same as "missing" operation.
+	EmptyMissing = "missing"
+	// EmptyEmpty treats null/""/[]/{} as empty.
+	EmptyEmpty = "empty"
+	// EmptyFalsy treats falsy values as empty. See ToBool.
+	EmptyFalsy = "falsy"
+)
+
+// AddOpDefault adds "default" operation to the JSONLogic instance. Param restriction:
+//   - Two to three params: the value, the default value and an optional emptiness mode.
+//   - The mode must be evaluated to one of "null" (default), "missing", "empty" and "falsy":
+//     - "null": only null is empty.
+//     - "missing": null or "" is empty, the same as "missing" operation.
+//     - "empty": null, "", [] or {} is empty.
+//     - "falsy": falsy value is empty. See http://jsonlogic.com/truthy.html
+//
+// It returns the value if it is not empty, otherwise the default value, which is evaluated only when needed.
+// Examples:
+//   - {"default":[{"var":"a"},1]} -> 1 if data["a"] is missing or null
+//   - {"default":[{"var":"a"},1,"falsy"]} -> 1 if data["a"] is missing or null/false/0/""/[]
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpDefault(jl *JSONLogic) {
+	jl.AddOperation("default", opDefault)
+}
+
+func opDefault(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	if len(params) < 2 || len(params) > 3 {
+		return nil, fmt.Errorf("default: expect 2 to 3 params")
+	}
+
+	mode := EmptyNull
+	if len(params) > 2 {
+		r, err := apply(params[2], data)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := r.(string)
+		if !ok {
+			return nil, fmt.Errorf("default: expect string as mode but got %T", r)
+		}
+		mode = m
+	}
+
+	res, err = apply(params[0], data)
+	if err != nil {
+		return nil, err
+	}
+
+	var empty bool
+	switch mode {
+	case EmptyNull:
+		empty = res == nil
+	case EmptyMissing:
+		empty = res == nil || res == ""
+	case EmptyEmpty:
+		switch r := res.(type) {
+		case nil:
+			empty = true
+		case string:
+			empty = r == ""
+		case []interface{}:
+			empty = len(r) == 0
+		case map[string]interface{}:
+			empty = len(r) == 0
+		}
+	case EmptyFalsy:
+		empty = !ToBool(res)
+	default:
+		return nil, fmt.Errorf("default: unknown mode %q", mode)
+	}
+
+	if !empty {
+		return res, nil
+	}
+	return apply(params[1], data)
+}
@@ -98,3 +98,81 @@ func TestOpMissingSome(t *testing.T) {
 		{Logic: `{"missing_some":[2,{"var":"pointer"}]}`, Data: `{"pointer":["x","y"]}`, Result: []interface{}{"x", "y"}},
 	}.Run(assert, jl)
 }
+
+func TestOpExists(t *testing.T) {
+	assert := assert.New(t)
+	jl := NewEmpty()
+	AddOpVar(jl)
+	AddOpExists(jl)
+	TestCases{
+		{Logic: `{"exists":"a"}`, Data: `{"a":1}`, Result: true},
+		{Logic: `{"exists":"a"}`, Data: `{"a":null}`, Result: true},
+		{Logic: `{"exists":"a"}`, Data: `{"a":""}`, Result: true},
+		{Logic: `{"exists":"a"}`, Data: `{"b":1}`, Result: false},
+		{Logic: `{"exists":"a.b"}`, Data: `{"a":{"b":null}}`, Result: true},
+		{Logic: `{"exists":"a.c"}`, Data: `{"a":{"b":null}}`, Result: false},
+		{Logic: `{"exists":"a.1"}`, Data: `{"a":[1,null]}`, Result: true},
+		{Logic: `{"exists":"a.2"}`, Data: `{"a":[1,null]}`, Result: false},
+		{Logic: `{"exists":["a","b"]}`, Data: `{"a":1,"b":2}`, Result: true},
+		{Logic: `{"exists":["a","b"]}`, Data: `{"a":1}`, Result: false},
+		{Logic: `{"exists":""}`, Data: `{"a":1}`, Result: true},
+		{Logic: `{"exists":{"var":"k"}}`, Data: `{"k":"a","a":1}`, Result: true},
+		// Err.
+		{Logic: `{"exists":[]}`, Data: `null`, Err: true},
+		{Logic: `{"exists":[[]]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
+
+func TestOpCoalesce(t *testing.T) {
+	assert := assert.New(t)
+	jl := NewEmpty()
+	AddOpVar(jl)
+	AddOpCoalesce(jl)
+	TestCases{
+		{Logic: `{"coalesce":[]}`, Data: `null`, Result: nil},
+		{Logic: `{"coalesce":[null,null]}`, Data: `null`, Result: nil},
+		{Logic: `{"coalesce":[{"var":"nickname"},{"var":"name"},"anonymous"]}`, Data: `{"nickname":null,"name":"Bob"}`, Result: "Bob"},
+		{Logic: `{"coalesce":[{"var":"nickname"},{"var":"name"},"anonymous"]}`, Data: `{}`, Result: "anonymous"},
+		{Logic: `{"coalesce":[{"var":"a"},1]}`, Data: `{"a":""}`, Result: ""},
+		{Logic: `{"coalesce":[{"var":"a"},1]}`, Data: `{"a":false}`, Result: false},
+		// Short-circuit.
+		{Logic: `{"coalesce":[1,{"xxx":1}]}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"coalesce":[null,{"xxx":1}]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
+
+func TestOpDefault(t *testing.T) {
+	assert := assert.New(t)
+	jl := NewEmpty()
+	AddOpVar(jl)
+	AddOpDefault(jl)
+	TestCases{
+		// "null" mode.
+		{Logic: `{"default":[{"var":"a"},1]}`, Data: `{}`, Result: float64(1)},
+		{Logic: `{"default":[{"var":"a"},1]}`, Data: `{"a":null}`, Result: float64(1)},
+		{Logic: `{"default":[{"var":"a"},1]}`, Data: `{"a":""}`, Result: ""},
+		{Logic: `{"default":[{"var":"a"},1,"null"]}`, Data: `{"a":0}`, Result: float64(0)},
+		// "missing" mode.
+		{Logic: `{"default":[{"var":"a"},1,"missing"]}`, Data: `{"a":null}`, Result: float64(1)},
+		{Logic: `{"default":[{"var":"a"},1,"missing"]}`, Data: `{"a":""}`, Result: float64(1)},
+		{Logic: `{"default":[{"var":"a"},1,"missing"]}`, Data: `{"a":[]}`, Result: []interface{}{}},
+		// "empty" mode.
+		{Logic: `{"default":[{"var":"a"},1,"empty"]}`, Data: `{"a":""}`, Result: float64(1)},
+		{Logic: `{"default":[{"var":"a"},1,"empty"]}`, Data: `{"a":[]}`, Result: float64(1)},
+		{Logic: `{"default":[{"var":"a"},1,"empty"]}`, Data: `{"a":{}}`, Result: float64(1)},
+		{Logic: `{"default":[{"var":"a"},1,"empty"]}`, Data: `{"a":false}`, Result: false},
+		{Logic: `{"default":[{"var":"a"},1,"empty"]}`, Data: `{"a":[0]}`, Result: []interface{}{float64(0)}},
+		// "falsy" mode.
+		{Logic: `{"default":[{"var":"a"},1,"falsy"]}`, Data: `{"a":false}`, Result: float64(1)},
+		{Logic: `{"default":[{"var":"a"},1,"falsy"]}`, Data: `{"a":0}`, Result: float64(1)},
+		{Logic: `{"default":[{"var":"a"},1,"falsy"]}`, Data: `{"a":{}}`, Result: map[string]interface{}{}},
+		// Default is evaluated only when needed.
+		{Logic: `{"default":[2,{"xxx":1}]}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"default":[null,{"var":"b"}]}`, Data: `{"b":3}`, Result: float64(3)},
+		// Err.
+		{Logic: `{"default":[1]}`, Data: `null`, Err: true},
+		{Logic: `{"default":[1,2,"xxx"]}`, Data: `null`, Err: true},
+		{Logic: `{"default":[1,2,3]}`, Data: `null`, Err: true},
+		{Logic: `{"default":[1,2,"null",4]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
@@ -0,0 +1,223 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// cell is a compiled input cell.
+type cell struct {
+	// logic is the condition, nil means any value.
+	logic interface{}
+	// cons is the constraint on the input value for static analysis, nil if unknown.
+	cons *constraint
+	// primitive is true if the cell only matches json primitive input values.
+	primitive bool
+}
+
+// compileCell compiles an input cell of the input expression expr. See DecisionTable for the syntax.
+func compileCell(expr interface{}, c interface{}) (*cell, error) {
+	var ret *cell
+	switch v := c.(type) {
+	case nil:
+		return &cell{cons: &constraint{any: true}}, nil
+	case bool, float64:
+		ret = eqCell(expr, []interface{}{v})
+	case string:
+		var err error
+		ret, err = parseUnaryTest(expr, v)
+		if err != nil {
+			return nil, err
+		}
+	case []interface{}:
+		for _, item := range v {
+			if !jsonlogic.IsPrimitive(item) {
+				return nil, fmt.Errorf("expect json primitives in list but got %T", item)
+			}
+		}
+		ret = eqCell(expr, v)
+	case map[string]interface{}:
+		return &cell{logic: v}, nil
+	default:
+		panic(fmt.Errorf("compileCell got non-json type %T", c))
+	}
+	// Literal cells compare the input value, which fails for non-primitives.
+	ret.primitive = ret.logic != nil
+	return ret, nil
+}
+
+// eqCell returns a cell testing the input value is strictly equal to one of values.
+func eqCell(expr interface{}, values []interface{}) *cell {
+	cons := &constraint{values: map[string]interface{}{}}
+	for _, v := range values {
+		cons.values[valueKey(v)] = v
+	}
+	if len(values) == 1 {
+		return &cell{
+			logic: map[string]interface{}{"===": []interface{}{expr, values[0]}},
+			cons:  cons,
+		}
+	}
+	return &cell{
+		logic: map[string]interface{}{"in": []interface{}{expr, values}},
+		cons:  cons,
+	}
+}
+
+// parseUnaryTest parses a cell in text.
+func parseUnaryTest(expr interface{}, s string) (*cell, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "-" {
+		return &cell{cons: &constraint{any: true}}, nil
+	}
+
+	// Ranges.
+	if (s[0] == '[' || s[0] == '(') && strings.Contains(s, "..") {
+		last := s[len(s)-1]
+		if last != ']' && last != ')' {
+			return nil, fmt.Errorf("invalid range %q", s)
+		}
+		parts := strings.SplitN(s[1:len(s)-1], "..", 2)
+		lo, err := parseNumber(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q: %s", s, err.Error())
+		}
+		hi, err := parseNumber(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q: %s", s, err.Error())
+		}
+		iv := &interval{lo: lo, hi: hi, loIncl: s[0] == '[', hiIncl: last == ']'}
+		return &cell{
+			logic: map[string]interface{}{"and": []interface{}{
+				compare(expr, iv.loOp(), lo),
+				compare(expr, iv.hiOp(), hi),
+			}},
+			cons: &constraint{interval: iv},
+		}, nil
+	}
+
+	// Comparisons.
+	for _, op := range []string{"<=", ">=", "!=", "<", ">", "="} {
+		if !strings.HasPrefix(s, op) {
+			continue
+		}
+		lit, err := parseLiteral(s[len(op):])
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "=":
+			return eqCell(expr, []interface{}{lit}), nil
+		case "!=":
+			return &cell{logic: map[string]interface{}{"!==": []interface{}{expr, lit}}}, nil
+		}
+		ret := &cell{logic: compare(expr, op, lit)}
+		if n, ok := lit.(float64); ok {
+			iv := &interval{lo: math.Inf(-1), hi: math.Inf(1)}
+			switch op {
+			case "<", "<=":
+				iv.hi, iv.hiIncl = n, op == "<="
+			case ">", ">=":
+				iv.lo, iv.loIncl = n, op == ">="
+			}
+			ret.cons = &constraint{interval: iv}
+		}
+		return ret, nil
+	}
+
+	// Literal or list of literals.
+	parts, err := splitList(s)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		lit, err := parseLiteral(part)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, lit)
+	}
+	return eqCell(expr, values), nil
+}
+
+// compare returns {op:[expr, value]}.
+func compare(expr interface{}, op string, value interface{}) interface{} {
+	return map[string]interface{}{op: []interface{}{expr, value}}
+}
+
+// splitList splits s by commas outside double quotes.
+func splitList(s string) ([]string, error) {
+	var ret []string
+	start := 0
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				ret = append(ret, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	return append(ret, s[start:]), nil
+}
+
+// parseLiteral parses a literal: a json string, number, true, false, null or an unquoted string.
+func parseLiteral(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "":
+		return nil, fmt.Errorf("empty literal")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if s[0] == '"' {
+		var ret string
+		if err := json.Unmarshal([]byte(s), &ret); err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		return ret, nil
+	}
+	if n, err := parseNumber(s); err == nil {
+		return n, nil
+	}
+	return s, nil
+}
+
+// parseNumber parses a finite number.
+func parseNumber(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+// valueKey returns a key of json primitive v, two values are strictly equal ("===") iff they have the same key.
+func valueKey(v interface{}) string {
+	if v == 0.0 {
+		// -0 and 0.
+		v = 0.0
+	}
+	return fmt.Sprintf("%T:%v", v, v)
+}
@@ -0,0 +1,85 @@
+package decision
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestCompileCell(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+	expr := map[string]interface{}{"var": "x"}
+
+	for _, tc := range []struct {
+		Cell    string
+		X       string
+		Matched bool
+	}{
+		{`null`, `1`, true},
+		{`"-"`, `"a"`, true},
+		{`"  "`, `null`, true},
+		{`1`, `1`, true},
+		{`1`, `"1"`, false},
+		{`true`, `true`, true},
+		{`"1"`, `1`, true},
+		{`"\"1\""`, `1`, false},
+		{`"\"1\""`, `"1"`, true},
+		{`"gold"`, `"gold"`, true},
+		{`" gold "`, `"gold"`, true},
+		{`"gold, silver"`, `"silver"`, true},
+		{`"gold, silver"`, `"bronze"`, false},
+		{`"\"a, b\", c"`, `"a, b"`, true},
+		{`"\"a, b\", c"`, `"a"`, false},
+		{`["a",1,null]`, `null`, true},
+		{`["a",1,null]`, `"1"`, false},
+		{`[]`, `null`, false},
+		{`"null"`, `null`, true},
+		{`"= gold"`, `"gold"`, true},
+		{`"!= gold"`, `"gold"`, false},
+		{`"!= gold"`, `"silver"`, true},
+		{`"< 18"`, `17.5`, true},
+		{`"< 18"`, `18`, false},
+		{`"<= 18"`, `18`, true},
+		{`">18"`, `18`, false},
+		{`">= 18"`, `18`, true},
+		{`"[18..65]"`, `65`, true},
+		{`"[18..65)"`, `65`, false},
+		{`"(18..65]"`, `18`, false},
+		{`"(18..65)"`, `20`, true},
+		{`"[-1.5..1.5]"`, `-1.5`, true},
+		{`{"===":[{"var":"y"},1]}`, `0`, false},
+	} {
+		var c, x interface{}
+		assert.NoError(json.Unmarshal([]byte(tc.Cell), &c))
+		assert.NoError(json.Unmarshal([]byte(tc.X), &x))
+		compiled, err := compileCell(expr, c)
+		if !assert.NoError(err, "cell=%s", tc.Cell) {
+			continue
+		}
+		logic := compiled.logic
+		if logic == nil {
+			logic = true
+		}
+		r, err := jl.Apply(logic, map[string]interface{}{"x": x, "y": 2.0})
+		assert.NoError(err, "cell=%s x=%s", tc.Cell, tc.X)
+		assert.Equal(tc.Matched, jsonlogic.ToBool(r), "cell=%s x=%s", tc.Cell, tc.X)
+	}
+
+	for _, c := range []interface{}{
+		"[1..x]",
+		"[1..2",
+		"(a..2)",
+		"a, ",
+		"< ",
+		"\"a",
+		"\"a\"b",
+		[]interface{}{[]interface{}{}},
+	} {
+		_, err := compileCell(expr, c)
+		assert.Error(err, "cell=%v", c)
+	}
+}
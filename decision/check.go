@@ -0,0 +1,256 @@
+package decision
+
+import (
+	"fmt"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// constraint is the set of values matched by a cell: any value, one of values, or numbers in interval.
+type constraint struct {
+	any      bool
+	values   map[string]interface{}
+	interval *interval
+}
+
+// interval is a numeric interval, lo/hi can be -Inf/+Inf.
+type interval struct {
+	lo, hi         float64
+	loIncl, hiIncl bool
+}
+
+func (iv *interval) loOp() string {
+	if iv.loIncl {
+		return ">="
+	}
+	return ">"
+}
+
+func (iv *interval) hiOp() string {
+	if iv.hiIncl {
+		return "<="
+	}
+	return "<"
+}
+
+func (iv *interval) empty() bool {
+	return iv.lo > iv.hi || (iv.lo == iv.hi && !(iv.loIncl && iv.hiIncl))
+}
+
+// contains tests whether v is in the interval, with the numeric conversion of "<"/">"/....
+func (iv *interval) contains(v interface{}) bool {
+	if !jsonlogic.IsPrimitive(v) {
+		return false
+	}
+	n, err := jsonlogic.ToNumeric(v)
+	if err != nil {
+		return false
+	}
+	return (n > iv.lo || (n == iv.lo && iv.loIncl)) && (n < iv.hi || (n == iv.hi && iv.hiIncl))
+}
+
+func (iv *interval) intersect(other *interval) *interval {
+	ret := *iv
+	if other.lo > ret.lo || (other.lo == ret.lo && !other.loIncl) {
+		ret.lo, ret.loIncl = other.lo, other.loIncl
+	}
+	if other.hi < ret.hi || (other.hi == ret.hi && !other.hiIncl) {
+		ret.hi, ret.hiIncl = other.hi, other.hiIncl
+	}
+	return &ret
+}
+
+func (iv *interval) covers(other *interval) bool {
+	if other.empty() {
+		return true
+	}
+	loOK := iv.lo < other.lo || (iv.lo == other.lo && (iv.loIncl || !other.loIncl))
+	hiOK := iv.hi > other.hi || (iv.hi == other.hi && (iv.hiIncl || !other.hiIncl))
+	return loOK && hiOK
+}
+
+// empty tests whether the constraint matches nothing.
+func (c *constraint) empty() bool {
+	if c.any {
+		return false
+	}
+	if c.interval != nil {
+		return c.interval.empty()
+	}
+	return len(c.values) == 0
+}
+
+// intersects tests whether some value matches both constraints.
+func (c *constraint) intersects(other *constraint) bool {
+	if c.empty() || other.empty() {
+		return false
+	}
+	if c.any || other.any {
+		return true
+	}
+	switch {
+	case c.interval != nil && other.interval != nil:
+		return !c.interval.intersect(other.interval).empty()
+	case c.interval != nil:
+		return other.anyValueIn(c.interval)
+	case other.interval != nil:
+		return c.anyValueIn(other.interval)
+	}
+	for k := range c.values {
+		if _, ok := other.values[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *constraint) anyValueIn(iv *interval) bool {
+	for _, v := range c.values {
+		if iv.contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers tests whether all values matching other also match c.
+func (c *constraint) covers(other *constraint) bool {
+	if c.any || other.empty() {
+		return true
+	}
+	if other.any {
+		return false
+	}
+	switch {
+	case c.interval != nil && other.interval != nil:
+		return c.interval.covers(other.interval)
+	case c.interval != nil:
+		for _, v := range other.values {
+			if !c.interval.contains(v) {
+				return false
+			}
+		}
+		return true
+	case other.interval != nil:
+		return false
+	}
+	for k := range other.values {
+		if _, ok := c.values[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// IssueKind is the kind of an Issue.
+type IssueKind string
+
+const (
+	// IssueOverlap means two rows can match the same data, which is an error for the unique hit policy.
+	IssueOverlap IssueKind = "overlap"
+	// IssueUnreachable means a row never produces output: a condition never matches, or every data it matches
+	// is matched by a preceding row (first hit policy) or a row of higher priority (priority hit policy).
+	IssueUnreachable IssueKind = "unreachable"
+)
+
+// Issue is a problem found by Check.
+type Issue struct {
+	Kind IssueKind
+	// Row is the index of the row with the issue.
+	Row int
+	// Other is the index of the overlapping/covering row, -1 if none.
+	Other int
+	// Message is a human readable description.
+	Message string
+}
+
+// Check finds overlapping and unreachable rows by static analysis of input cells. Only simple cells (any,
+// literals, lists, comparisons with numbers and ranges) are analyzed: rows with other cells or a "when"
+// condition are never reported as overlapping or covering others, so an empty result does not prove
+// there is no issue. The table must be compiled.
+func (t *DecisionTable) Check() []Issue {
+	var issues []Issue
+rows:
+	for j, rj := range t.rows {
+		if rj.never() {
+			issues = append(issues, Issue{
+				Kind:    IssueUnreachable,
+				Row:     j,
+				Other:   -1,
+				Message: fmt.Sprintf("row %d is unreachable: a condition never matches", j),
+			})
+			continue
+		}
+		for i, ri := range t.rows {
+			if i == j || ri.never() {
+				continue
+			}
+			switch t.HitPolicy {
+			case HitUnique:
+				if i < j && ri.overlaps(rj) {
+					issues = append(issues, Issue{
+						Kind:    IssueOverlap,
+						Row:     j,
+						Other:   i,
+						Message: fmt.Sprintf("row %d overlaps row %d", j, i),
+					})
+				}
+			case HitFirst, HitPriority:
+				shadows := i < j
+				if t.HitPolicy == HitPriority {
+					pi, pj := t.Rows[i].Priority, t.Rows[j].Priority
+					shadows = pi > pj || (pi == pj && i < j)
+				}
+				if shadows && ri.covers(rj) {
+					issues = append(issues, Issue{
+						Kind:    IssueUnreachable,
+						Row:     j,
+						Other:   i,
+						Message: fmt.Sprintf("row %d is unreachable: covered by row %d", j, i),
+					})
+					// One covering row is enough.
+					continue rows
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// never tests whether the row statically never matches.
+func (r *row) never() bool {
+	for _, c := range r.cells {
+		if c.cons != nil && c.cons.empty() {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps tests whether both rows surely match some data.
+func (r *row) overlaps(other *row) bool {
+	if r.when != nil || other.when != nil {
+		return false
+	}
+	for k, c := range r.cells {
+		oc := other.cells[k]
+		if c.cons == nil || oc.cons == nil || !c.cons.intersects(oc.cons) {
+			return false
+		}
+	}
+	return true
+}
+
+// covers tests whether r surely matches all data matched by other.
+func (r *row) covers(other *row) bool {
+	if r.when != nil {
+		return false
+	}
+	for k, c := range r.cells {
+		oc := other.cells[k]
+		if c.cons == nil || (oc.cons == nil && !c.cons.any) || (oc.cons != nil && !c.cons.covers(oc.cons)) {
+			return false
+		}
+	}
+	return true
+}
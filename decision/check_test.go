@@ -0,0 +1,135 @@
+package decision
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	type issue struct {
+		Kind  IssueKind
+		Row   int
+		Other int
+	}
+	for _, tc := range []struct {
+		Name      string
+		HitPolicy HitPolicy
+		Rows      [][]interface{}
+		Issues    []issue
+	}{
+		{
+			Name:      "disjoint",
+			HitPolicy: HitUnique,
+			Rows:      [][]interface{}{{"< 18", "-"}, {"[18..65)", "-"}, {">= 65", "-"}},
+		},
+		{
+			Name:      "overlapping ranges",
+			HitPolicy: HitUnique,
+			Rows:      [][]interface{}{{"<= 18", "-"}, {"[18..65)", "-"}},
+			Issues:    []issue{{IssueOverlap, 1, 0}},
+		},
+		{
+			Name:      "disjoint by another column",
+			HitPolicy: HitUnique,
+			Rows:      [][]interface{}{{"<= 18", "a"}, {"[18..65)", "b, c"}},
+		},
+		{
+			Name:      "overlapping lists",
+			HitPolicy: HitUnique,
+			Rows:      [][]interface{}{{"-", "a, b"}, {"-", "b, c"}, {"-", "c"}},
+			Issues:    []issue{{IssueOverlap, 1, 0}, {IssueOverlap, 2, 1}},
+		},
+		{
+			Name:      "literal in range",
+			HitPolicy: HitUnique,
+			Rows:      [][]interface{}{{"(0..10)", "-"}, {"5, 20", "-"}, {"10", "-"}},
+			Issues:    []issue{{IssueOverlap, 1, 0}},
+		},
+		{
+			Name:      "strict equality",
+			HitPolicy: HitUnique,
+			Rows:      [][]interface{}{{"1", "-"}, {"\"1\"", "-"}},
+		},
+		{
+			Name:      "unknown conditions",
+			HitPolicy: HitUnique,
+			Rows:      [][]interface{}{{"!= a", "-"}, {"b", "-"}, {map[string]interface{}{"var": "x"}, "-"}},
+		},
+		{
+			Name:      "covered by preceding row",
+			HitPolicy: HitFirst,
+			Rows:      [][]interface{}{{"< 65", "-"}, {"[18..30]", "a"}, {"-", "-"}, {"70", "-"}},
+			Issues:    []issue{{IssueUnreachable, 1, 0}, {IssueUnreachable, 3, 2}},
+		},
+		{
+			Name:      "partially covered",
+			HitPolicy: HitFirst,
+			Rows:      [][]interface{}{{"< 65", "a, b"}, {"[18..70]", "a"}, {"[18..30]", "c"}},
+		},
+		{
+			Name:      "covered list",
+			HitPolicy: HitFirst,
+			Rows:      [][]interface{}{{"-", "a, b, c"}, {"-", "c, a"}, {"-", "c, d"}},
+			Issues:    []issue{{IssueUnreachable, 1, 0}},
+		},
+		{
+			Name:      "unknown condition covered by any",
+			HitPolicy: HitFirst,
+			Rows:      [][]interface{}{{"-", "-"}, {"!= x", map[string]interface{}{"var": "y"}}},
+			Issues:    []issue{{IssueUnreachable, 1, 0}},
+		},
+		{
+			Name:      "never matches",
+			HitPolicy: HitCollect,
+			Rows:      [][]interface{}{{"[10..5]", "-"}, {"(5..5]", "-"}, {"[5..5]", "-"}, {"-", "-"}},
+			Issues:    []issue{{IssueUnreachable, 0, -1}, {IssueUnreachable, 1, -1}},
+		},
+		{
+			Name:      "priority",
+			HitPolicy: HitPriority,
+			Rows:      [][]interface{}{{"< 18", "-"}, {"< 65", "-"}, {"< 10", "-"}},
+			Issues:    []issue{{IssueUnreachable, 0, 1}, {IssueUnreachable, 2, 1}},
+		},
+	} {
+		table := &DecisionTable{
+			HitPolicy: tc.HitPolicy,
+			Inputs:    []Input{{Name: "age"}, {Name: "tier"}},
+			Outputs:   []string{"out"},
+		}
+		for i, conds := range tc.Rows {
+			priority := 0.0
+			if tc.HitPolicy == HitPriority {
+				// The second row has the highest priority.
+				priority = []float64{1, 3, 2}[i]
+			}
+			table.Rows = append(table.Rows, Row{Conditions: conds, Outputs: []interface{}{float64(i)}, Priority: priority})
+		}
+		if !assert.NoError(table.Compile(), tc.Name) {
+			continue
+		}
+		var issues []issue
+		for _, is := range table.Check() {
+			assert.NotEmpty(is.Message)
+			issues = append(issues, issue{is.Kind, is.Row, is.Other})
+		}
+		assert.Equal(tc.Issues, issues, tc.Name)
+	}
+
+	// Rows with "when" neither overlap nor cover.
+	table := &DecisionTable{
+		HitPolicy: HitUnique,
+		Inputs:    []Input{{Name: "age"}},
+		Outputs:   []string{"out"},
+		Rows: []Row{
+			{Conditions: []interface{}{"-"}, When: map[string]interface{}{"var": "x"}, Outputs: []interface{}{1.0}},
+			{Conditions: []interface{}{"-"}, Outputs: []interface{}{2.0}},
+		},
+	}
+	assert.NoError(table.Compile())
+	assert.Empty(table.Check())
+	table.HitPolicy = HitFirst
+	assert.Empty(table.Check())
+}
@@ -0,0 +1,301 @@
+// Package decision implements decision tables evaluated by json logic: rows of input conditions and output
+// values, like a spreadsheet.
+//
+// For example, with the first hit policy:
+//   | in:age    | in:tier      | out:discount |
+//   | < 18      | -            | 0.2          |
+//   | [18..65)  | gold, silver | 0.1          |
+//   | -         | -            | 0            |
+package decision
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// HitPolicy decides the output when multiple rows match.
+type HitPolicy string
+
+const (
+	// HitFirst outputs the first matching row.
+	HitFirst HitPolicy = "first"
+	// HitUnique outputs the only matching row, and it is an error if more than one row match.
+	HitUnique HitPolicy = "unique"
+	// HitCollect outputs all matching rows in order.
+	HitCollect HitPolicy = "collect"
+	// HitPriority outputs the matching row with the highest Row.Priority, the first one if there are ties.
+	HitPriority HitPolicy = "priority"
+)
+
+// DecisionTable is a decision table. It must be compiled by Compile before use (ParseJSON and ParseCSV
+// return compiled tables), and must not be modified after that. A compiled table is safe for concurrent use.
+//
+// Each input column has an expression (json logic evaluated against data, {"var":name} by default) giving
+// the input value, and each input cell of a row is a condition on the input value:
+//   - null, "" or "-": any value.
+//   - A number or boolean, or a string of literal (see below): the input strictly equals ("===") it.
+//   - An array of json primitives, or a string of comma separated literals: the input equals one of them.
+//   - A string of comparison with a literal: "< 18", "<= 18", "> 18", ">= 18", "= gold" or "!= gold".
+//   - A string of numeric range: "[18..65]", "(18..65)", "[18..65)" or "(18..65]" (round brackets exclude
+//     the end).
+//   - An object: json logic evaluated against the whole data instead of the input value.
+//
+// Literals in strings are json strings ("\"a, b\""), numbers, true, false, null, or unquoted strings
+// (trimmed). Besides input cells, a row can have a "when" json logic condition against the whole data.
+//
+// A row matches if all its conditions are truthy. Conditions are compiled to json logic and evaluated by
+// JSONLogic.Apply. Cells other than objects only match json primitive input values, a row is skipped instead
+// of failing the evaluation if such a cell gets an array or object input value. The output of a row is an object of output column names to the output values (not evaluated).
+type DecisionTable struct {
+	// Name is an optional name.
+	Name string `json:"name,omitempty"`
+
+	// HitPolicy is HitFirst if empty.
+	HitPolicy HitPolicy `json:"hit_policy,omitempty"`
+
+	Inputs  []Input  `json:"inputs"`
+	Outputs []string `json:"outputs"`
+	Rows    []Row    `json:"rows"`
+
+	exprs []interface{}
+	rows  []*row
+}
+
+// Input is an input column.
+type Input struct {
+	Name string `json:"name"`
+	// Expr is the json logic of the input value, {"var":Name} if nil.
+	Expr interface{} `json:"expr,omitempty"`
+}
+
+// Row is a row of decision table.
+type Row struct {
+	// Conditions are input cells, one for each input column.
+	Conditions []interface{} `json:"conditions"`
+	// When is an optional json logic condition against the whole data.
+	When interface{} `json:"when,omitempty"`
+	// Outputs are output values, one for each output column.
+	Outputs []interface{} `json:"outputs"`
+	// Priority is used by HitPriority, higher first.
+	Priority float64 `json:"priority,omitempty"`
+}
+
+// row is a compiled row.
+type row struct {
+	cells  []*cell
+	when   interface{}
+	logic  interface{}
+	output map[string]interface{}
+}
+
+// Compile validates and compiles the table.
+func (t *DecisionTable) Compile() error {
+	switch t.HitPolicy {
+	case "":
+		t.HitPolicy = HitFirst
+	case HitFirst, HitUnique, HitCollect, HitPriority:
+	default:
+		return fmt.Errorf("decision: unknown hit policy %q", t.HitPolicy)
+	}
+
+	names := map[string]struct{}{}
+	for _, name := range t.inputNames() {
+		if err := checkName(name, names); err != nil {
+			return fmt.Errorf("decision: input: %s", err.Error())
+		}
+	}
+	if len(t.Outputs) == 0 {
+		return fmt.Errorf("decision: no output")
+	}
+	for _, name := range t.Outputs {
+		if err := checkName(name, names); err != nil {
+			return fmt.Errorf("decision: output: %s", err.Error())
+		}
+	}
+
+	t.exprs = make([]interface{}, 0, len(t.Inputs))
+	for _, input := range t.Inputs {
+		expr := input.Expr
+		if expr == nil {
+			expr = map[string]interface{}{"var": input.Name}
+		}
+		t.exprs = append(t.exprs, expr)
+	}
+	rows := make([]*row, 0, len(t.Rows))
+	for i, r := range t.Rows {
+		compiled, err := t.compileRow(r)
+		if err != nil {
+			return fmt.Errorf("decision: row %d: %s", i, err.Error())
+		}
+		rows = append(rows, compiled)
+	}
+	t.rows = rows
+	return nil
+}
+
+func (t *DecisionTable) inputNames() []string {
+	ret := make([]string, 0, len(t.Inputs))
+	for _, input := range t.Inputs {
+		ret = append(ret, input.Name)
+	}
+	return ret
+}
+
+func checkName(name string, names map[string]struct{}) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("empty column name")
+	}
+	if _, ok := names[name]; ok {
+		return fmt.Errorf("duplicated column name %q", name)
+	}
+	names[name] = struct{}{}
+	return nil
+}
+
+func (t *DecisionTable) compileRow(r Row) (*row, error) {
+	if len(r.Conditions) != len(t.Inputs) {
+		return nil, fmt.Errorf("expect %d conditions but got %d", len(t.Inputs), len(r.Conditions))
+	}
+	if len(r.Outputs) != len(t.Outputs) {
+		return nil, fmt.Errorf("expect %d outputs but got %d", len(t.Outputs), len(r.Outputs))
+	}
+
+	ret := &row{
+		when:   r.When,
+		output: make(map[string]interface{}, len(t.Outputs)),
+	}
+	var conds []interface{}
+	for i, input := range t.Inputs {
+		c, err := compileCell(t.exprs[i], r.Conditions[i])
+		if err != nil {
+			return nil, fmt.Errorf("input %q: %s", input.Name, err.Error())
+		}
+		ret.cells = append(ret.cells, c)
+		if c.logic != nil {
+			conds = append(conds, c.logic)
+		}
+	}
+	if r.When != nil {
+		conds = append(conds, r.When)
+	}
+	switch len(conds) {
+	case 0:
+		ret.logic = true
+	case 1:
+		ret.logic = conds[0]
+	default:
+		ret.logic = map[string]interface{}{"and": conds}
+	}
+
+	for i, name := range t.Outputs {
+		ret.output[name] = r.Outputs[i]
+	}
+	return ret, nil
+}
+
+// Matches returns indices of all matching rows in order. If jl is nil, jsonlogic.DefaultJSONLogic is used.
+func (t *DecisionTable) Matches(jl *jsonlogic.JSONLogic, data interface{}) ([]int, error) {
+	return t.matches(jl, data, false)
+}
+
+func (t *DecisionTable) matches(jl *jsonlogic.JSONLogic, data interface{}, firstOnly bool) ([]int, error) {
+	if t.rows == nil && len(t.Rows) > 0 {
+		return nil, fmt.Errorf("decision: table not compiled")
+	}
+	if jl == nil {
+		jl = jsonlogic.DefaultJSONLogic
+	}
+	// primitive[i] is nil until input i is evaluated.
+	primitive := make([]*bool, len(t.Inputs))
+	var ret []int
+	for i, r := range t.rows {
+		ok, err := t.primitiveInputs(jl, data, r, primitive)
+		if err != nil {
+			return nil, fmt.Errorf("decision: row %d: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		res, err := jl.Apply(r.logic, data)
+		if err != nil {
+			return nil, fmt.Errorf("decision: row %d: %w", i, err)
+		}
+		if !jsonlogic.ToBool(res) {
+			continue
+		}
+		ret = append(ret, i)
+		if firstOnly {
+			break
+		}
+	}
+	return ret, nil
+}
+
+// primitiveInputs returns true if the input values are json primitives for all cells of r which only match
+// primitives, the results are cached in primitive.
+func (t *DecisionTable) primitiveInputs(jl *jsonlogic.JSONLogic, data interface{}, r *row, primitive []*bool) (bool, error) {
+	for i, c := range r.cells {
+		if !c.primitive {
+			continue
+		}
+		if primitive[i] == nil {
+			v, err := jl.Apply(t.exprs[i], data)
+			if err != nil {
+				return false, fmt.Errorf("input %q: %w", t.Inputs[i].Name, err)
+			}
+			p := jsonlogic.IsPrimitive(v)
+			primitive[i] = &p
+		}
+		if !*primitive[i] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Evaluate evaluates the table against data by the hit policy. If jl is nil, jsonlogic.DefaultJSONLogic
+// is used. The result is the output object of the selected row (null if no row matches), or an array of
+// output objects for HitCollect.
+func (t *DecisionTable) Evaluate(jl *jsonlogic.JSONLogic, data interface{}) (interface{}, error) {
+	matches, err := t.matches(jl, data, t.HitPolicy == HitFirst)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.HitPolicy == HitCollect {
+		ret := make([]interface{}, 0, len(matches))
+		for _, i := range matches {
+			ret = append(ret, t.output(i))
+		}
+		return ret, nil
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	switch t.HitPolicy {
+	case HitUnique:
+		if len(matches) > 1 {
+			return nil, fmt.Errorf("decision: unique hit policy violated: rows %v match", matches)
+		}
+	case HitPriority:
+		best := matches[0]
+		for _, i := range matches[1:] {
+			if t.Rows[i].Priority > t.Rows[best].Priority {
+				best = i
+			}
+		}
+		return t.output(best), nil
+	}
+	return t.output(matches[0]), nil
+}
+
+// output returns a copy of the output of row i.
+func (t *DecisionTable) output(i int) map[string]interface{} {
+	ret := make(map[string]interface{}, len(t.rows[i].output))
+	for k, v := range t.rows[i].output {
+		ret[k] = v
+	}
+	return ret
+}
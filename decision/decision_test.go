@@ -0,0 +1,133 @@
+package decision
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestEvaluate(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+
+	newTable := func(hitPolicy HitPolicy) *DecisionTable {
+		return &DecisionTable{
+			HitPolicy: hitPolicy,
+			Inputs: []Input{
+				{Name: "age"},
+				{Name: "tier", Expr: map[string]interface{}{"var": "customer.tier"}},
+			},
+			Outputs: []string{"discount", "label"},
+			Rows: []Row{
+				{Conditions: []interface{}{"< 18", nil}, Outputs: []interface{}{0.2, "minor"}, Priority: 1},
+				{Conditions: []interface{}{"[18..65)", "gold, silver"}, Outputs: []interface{}{0.1, "member"}, Priority: 2},
+				{Conditions: []interface{}{"-", "-"}, When: map[string]interface{}{"var": "vip"}, Outputs: []interface{}{0.3, "vip"}, Priority: 3},
+				{Conditions: []interface{}{"-", "-"}, Outputs: []interface{}{0.0, "default"}},
+			},
+		}
+	}
+	output := func(discount float64, label string) map[string]interface{} {
+		return map[string]interface{}{"discount": discount, "label": label}
+	}
+
+	for _, tc := range []struct {
+		HitPolicy HitPolicy
+		Data      string
+		Matches   []int
+		Result    interface{}
+		Err       bool
+	}{
+		{HitFirst, `{"age":10}`, []int{0, 3}, output(0.2, "minor"), false},
+		{HitFirst, `{"age":30,"customer":{"tier":"gold"}}`, []int{1, 3}, output(0.1, "member"), false},
+		{HitFirst, `{"age":30,"customer":{"tier":"bronze"}}`, []int{3}, output(0, "default"), false},
+		{HitFirst, `{"age":30,"customer":{"tier":["gold"]}}`, []int{3}, output(0, "default"), false},
+		{HitCollect, `{"age":[10],"vip":true}`, []int{2, 3}, []interface{}{output(0.3, "vip"), output(0, "default")}, false},
+		{HitFirst, `{"age":30,"vip":true}`, []int{2, 3}, output(0.3, "vip"), false},
+		{HitCollect, `{"age":10,"vip":true}`, []int{0, 2, 3}, []interface{}{output(0.2, "minor"), output(0.3, "vip"), output(0, "default")}, false},
+		{HitPriority, `{"age":10,"vip":true}`, []int{0, 2, 3}, output(0.3, "vip"), false},
+		{HitPriority, `{"age":30,"customer":{"tier":"gold"}}`, []int{1, 3}, output(0.1, "member"), false},
+		{HitUnique, `{"age":10}`, []int{0, 3}, nil, true},
+	} {
+		var data interface{}
+		assert.NoError(json.Unmarshal([]byte(tc.Data), &data))
+		table := newTable(tc.HitPolicy)
+		assert.NoError(table.Compile())
+
+		matches, err := table.Matches(jl, data)
+		assert.NoError(err)
+		assert.Equal(tc.Matches, matches, "policy=%s data=%s", tc.HitPolicy, tc.Data)
+
+		r, err := table.Evaluate(jl, data)
+		if tc.Err {
+			assert.Error(err, "policy=%s data=%s", tc.HitPolicy, tc.Data)
+			continue
+		}
+		assert.NoError(err, "policy=%s data=%s", tc.HitPolicy, tc.Data)
+		assert.Equal(tc.Result, r, "policy=%s data=%s", tc.HitPolicy, tc.Data)
+	}
+
+	// No match.
+	table := &DecisionTable{
+		HitPolicy: HitUnique,
+		Inputs:    []Input{{Name: "a"}},
+		Outputs:   []string{"b"},
+		Rows:      []Row{{Conditions: []interface{}{1.0}, Outputs: []interface{}{"one"}}},
+	}
+	assert.NoError(table.Compile())
+	r, err := table.Evaluate(nil, map[string]interface{}{"a": 2.0})
+	assert.NoError(err)
+	assert.Nil(r)
+	r, err = table.Evaluate(nil, map[string]interface{}{"a": 1.0})
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{"b": "one"}, r)
+	table.HitPolicy = HitCollect
+	r, err = table.Evaluate(nil, map[string]interface{}{"a": 2.0})
+	assert.NoError(err)
+	assert.Equal([]interface{}{}, r)
+
+	// Errors of conditions.
+	table = &DecisionTable{
+		Inputs:  []Input{{Name: "a"}},
+		Outputs: []string{"b"},
+		Rows:    []Row{{Conditions: []interface{}{map[string]interface{}{"unknown_op": 1.0}}, Outputs: []interface{}{1.0}}},
+	}
+	assert.NoError(table.Compile())
+	assert.Equal(HitFirst, table.HitPolicy)
+	_, err = table.Evaluate(jl, nil)
+	assert.Error(err)
+
+	// Not compiled.
+	table = newTable(HitFirst)
+	_, err = table.Evaluate(jl, nil)
+	assert.Error(err)
+}
+
+func TestCompile(t *testing.T) {
+	assert := assert.New(t)
+	valid := func() *DecisionTable {
+		return &DecisionTable{
+			Inputs:  []Input{{Name: "a"}},
+			Outputs: []string{"b"},
+			Rows:    []Row{{Conditions: []interface{}{"-"}, Outputs: []interface{}{1.0}}},
+		}
+	}
+	assert.NoError(valid().Compile())
+
+	for name, modify := range map[string]func(t *DecisionTable){
+		"unknown hit policy":    func(t *DecisionTable) { t.HitPolicy = "any" },
+		"no output":             func(t *DecisionTable) { t.Outputs = nil },
+		"empty input name":      func(t *DecisionTable) { t.Inputs[0].Name = " " },
+		"duplicated name":       func(t *DecisionTable) { t.Outputs[0] = "a" },
+		"too many conditions":   func(t *DecisionTable) { t.Rows[0].Conditions = append(t.Rows[0].Conditions, "-") },
+		"too few outputs":       func(t *DecisionTable) { t.Rows[0].Outputs = nil },
+		"invalid condition":     func(t *DecisionTable) { t.Rows[0].Conditions[0] = "[1..2" },
+		"non-primitive in list": func(t *DecisionTable) { t.Rows[0].Conditions[0] = []interface{}{[]interface{}{}} },
+	} {
+		table := valid()
+		modify(table)
+		assert.Error(table.Compile(), name)
+	}
+}
@@ -0,0 +1,141 @@
+package decision
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseJSON parses and compiles a decision table in json, the same structure as DecisionTable, e.g.:
+//   {"hit_policy":"first","inputs":[{"name":"age"}],"outputs":["discount"],
+//    "rows":[{"conditions":["< 18"],"outputs":[0.2]},{"conditions":["-"],"outputs":[0]}]}
+//
+// Unknown fields are errors to catch typos.
+func ParseJSON(b []byte) (*DecisionTable, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	t := &DecisionTable{}
+	if err := dec.Decode(t); err != nil {
+		return nil, fmt.Errorf("decision: %s", err.Error())
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("decision: unexpected data after decision table")
+	}
+	if err := t.Compile(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ParseCSV parses and compiles a decision table in CSV with the hit policy. The first record is the header,
+// names of columns are:
+//   - "in:<name>": an input column, the input value is {"var":"<name>"}. Cells are strings of conditions,
+//     see DecisionTable.
+//   - "out:<name>": an output column. Cells are json values, or strings if they are not valid json.
+//     Empty cells are null.
+//   - "when": json logic conditions against the whole data, optional.
+//   - "priority": numeric priorities, optional.
+//
+// Other records are rows. For example:
+//   in:age,in:tier,out:discount
+//   < 18,-,0.2
+//   [18..65),"gold, silver",0.1
+//   -,-,0
+func ParseCSV(r io.Reader, hitPolicy HitPolicy) (*DecisionTable, error) {
+	reader := csv.NewReader(r)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("decision: %s", err.Error())
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("decision: missing header")
+	}
+
+	t := &DecisionTable{
+		HitPolicy: hitPolicy,
+	}
+	const (
+		colInput = iota
+		colOutput
+		colWhen
+		colPriority
+	)
+	header := records[0]
+	kinds := make([]int, 0, len(header))
+	seen := map[int]bool{}
+	for _, h := range header {
+		h = strings.TrimSpace(h)
+		switch {
+		case strings.HasPrefix(h, "in:"):
+			t.Inputs = append(t.Inputs, Input{Name: strings.TrimSpace(h[3:])})
+			kinds = append(kinds, colInput)
+		case strings.HasPrefix(h, "out:"):
+			t.Outputs = append(t.Outputs, strings.TrimSpace(h[4:]))
+			kinds = append(kinds, colOutput)
+		case h == "when" || h == "priority":
+			kind := colWhen
+			if h == "priority" {
+				kind = colPriority
+			}
+			if seen[kind] {
+				return nil, fmt.Errorf("decision: duplicated column %q", h)
+			}
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		default:
+			return nil, fmt.Errorf("decision: unknown column %q, expect \"in:<name>\", \"out:<name>\", \"when\" or \"priority\"", h)
+		}
+	}
+
+	for n, record := range records[1:] {
+		// The row number in the CSV (1-based, including the header).
+		line := n + 2
+		r := Row{}
+		for i, s := range record {
+			s = strings.TrimSpace(s)
+			switch kinds[i] {
+			case colInput:
+				r.Conditions = append(r.Conditions, s)
+			case colOutput:
+				r.Outputs = append(r.Outputs, parseOutput(s))
+			case colWhen:
+				if s == "" {
+					break
+				}
+				if err := json.Unmarshal([]byte(s), &r.When); err != nil {
+					return nil, fmt.Errorf("decision: record %d: invalid \"when\": %s", line, err.Error())
+				}
+			case colPriority:
+				if s == "" {
+					break
+				}
+				p, err := parseNumber(s)
+				if err != nil {
+					return nil, fmt.Errorf("decision: record %d: invalid \"priority\": %s", line, err.Error())
+				}
+				r.Priority = p
+			}
+		}
+		t.Rows = append(t.Rows, r)
+	}
+
+	if err := t.Compile(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseOutput parses an output cell in CSV.
+func parseOutput(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	var ret interface{}
+	if err := json.Unmarshal([]byte(s), &ret); err == nil {
+		return ret
+	}
+	return s
+}
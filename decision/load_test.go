@@ -0,0 +1,89 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	table, err := ParseJSON([]byte(`{
+  "name":"discount",
+  "hit_policy":"unique",
+  "inputs":[{"name":"age"},{"name":"tier","expr":{"var":"customer.tier"}}],
+  "outputs":["discount"],
+  "rows":[
+    {"conditions":["< 18",null],"outputs":[0.2]},
+    {"conditions":[">= 18",["gold","silver"]],"outputs":[0.1]},
+    {"conditions":[">= 18","bronze"],"when":{"var":"vip"},"outputs":[0.05]}
+  ]
+}`))
+	assert.NoError(err)
+	assert.Equal("discount", table.Name)
+	assert.Equal(HitUnique, table.HitPolicy)
+	assert.Empty(table.Check())
+	r, err := table.Evaluate(nil, map[string]interface{}{
+		"age":      30.0,
+		"customer": map[string]interface{}{"tier": "silver"},
+	})
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{"discount": 0.1}, r)
+
+	for _, s := range []string{
+		`{"hit_policy":"first","inputs":[],"outputs":["a"],"rows":[],"colums":[]}`,
+		`{"hit_policy":"any","inputs":[],"outputs":["a"],"rows":[]}`,
+		`{"inputs":[{"name":"a"}],"outputs":["b"],"rows":[{"conditions":["(1..2"],"outputs":[1]}]}`,
+		`{"inputs":[],"outputs":["a"],"rows":[]} {}`,
+		`{`,
+	} {
+		_, err := ParseJSON([]byte(s))
+		assert.Error(err, s)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	table, err := ParseCSV(strings.NewReader(`in:age, in:tier ,out:discount,out:note,when,priority
+< 18,-,0.2,minor,,1
+[18..65),"gold, silver",0.1,"{""a"":1}",,2
+-,-,0.3,,"{""var"":""vip""}",3
+-,-,0,"""quoted""",,
+`), HitPriority)
+	assert.NoError(err)
+	assert.Equal([]Input{{Name: "age"}, {Name: "tier"}}, table.Inputs)
+	assert.Equal([]string{"discount", "note"}, table.Outputs)
+	assert.Equal([]Row{
+		{Conditions: []interface{}{"< 18", "-"}, Outputs: []interface{}{0.2, "minor"}, Priority: 1},
+		{Conditions: []interface{}{"[18..65)", "gold, silver"}, Outputs: []interface{}{0.1, map[string]interface{}{"a": 1.0}}, Priority: 2},
+		{Conditions: []interface{}{"-", "-"}, When: map[string]interface{}{"var": "vip"}, Outputs: []interface{}{0.3, nil}, Priority: 3},
+		{Conditions: []interface{}{"-", "-"}, Outputs: []interface{}{0.0, "quoted"}},
+	}, table.Rows)
+	assert.Empty(table.Check())
+
+	r, err := table.Evaluate(nil, map[string]interface{}{"age": 30.0, "tier": "gold"})
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{"discount": 0.1, "note": map[string]interface{}{"a": 1.0}}, r)
+	r, err = table.Evaluate(nil, map[string]interface{}{"age": 30.0, "tier": "gold", "vip": true})
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{"discount": 0.3, "note": nil}, r)
+
+	for _, s := range []string{
+		"",
+		"in:a,out:b,extra\n1,2,3\n",
+		"in:a,out:b,when,when\n1,2,,\n",
+		"in:a,out:b,when\n1,2,{\n",
+		"in:a,out:b,priority\n1,2,high\n",
+		"in:a,out:b\n1,2,3\n",
+		"in:a,out:b\n[1..2,2\n",
+		"in:,out:b\n1,2\n",
+	} {
+		_, err := ParseCSV(strings.NewReader(s), HitFirst)
+		assert.Error(err, s)
+	}
+	_, err = ParseCSV(strings.NewReader("in:a,out:b\n1,2\n"), "any")
+	assert.Error(err)
+}
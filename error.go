@@ -0,0 +1,114 @@
+package jsonlogic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ThrownError is the error raised by "throw" operation. The Go caller can get it from the error returned by
+// Apply using errors.As.
+type ThrownError struct {
+	// Code is a non-empty code identifying the error.
+	Code string
+	// Message is an optional human readable message.
+	Message string
+}
+
+// Error implements error interface.
+func (e *ThrownError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("throw: %s", e.Code)
+	}
+	return fmt.Sprintf("throw: %s: %s", e.Code, e.Message)
+}
+
+// AddOpThrow adds "throw" operation to the JSONLogic instance. Param restriction:
+//   - One to two params: the first evaluated to a non-empty string as the error code, and the second
+//     evaluated to a json primitive as the error message.
+//
+// It always returns a *ThrownError, for example: {"throw":["out_of_stock","sku 123 is out of stock"]}.
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpThrow(jl *JSONLogic) {
+	jl.AddOperation("throw", opThrow)
+}
+
+func opThrow(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	if len(params) < 1 || len(params) > 2 {
+		return nil, fmt.Errorf("throw: expect 1 to 2 params")
+	}
+	params, err = ApplyParams(apply, params, data)
+	if err != nil {
+		return
+	}
+
+	code, ok := params[0].(string)
+	if !ok || code == "" {
+		return nil, fmt.Errorf("throw: expect non-empty string as error code but got %v", params[0])
+	}
+	e := &ThrownError{
+		Code: code,
+	}
+	if len(params) > 1 {
+		e.Message, err = ToString(params[1])
+		if err != nil {
+			return nil, fmt.Errorf("throw: %s", err.Error())
+		}
+	}
+	return nil, e
+}
+
+// AddOpTry adds "try" operation to the JSONLogic instance. Param restriction:
+//   - At least one param.
+//
+// Params are evaluated in order until one succeeds and its result is returned. If all fail, the last error
+// is returned. Inside a param (except the first), the error of the previous param can be read by {"val":"error"},
+// which is an object {"code":...,"message":...}, code is null if the error is not raised by "throw" and
+// message is the error string then. The binding shadows an "error" key of data in "val", which can still be
+// read by {"val":[[0],"error"]} (or by "var").
+// Errors of exceeding max depth (see JSONLogic.SetMaxDepth) and of a done context (see JSONLogic.ApplyContext)
+// are not caught. Examples:
+//   - {"try":[{"/":[{"var":"a"},{"var":"b"}]},0]} -> 0 if b is 0
+//   - {"try":[{"throw":"x"},{"val":["error","code"]}]} -> "x"
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpTry(jl *JSONLogic) {
+	jl.AddOperation("try", opTry)
+}
+
+func opTry(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	if len(params) < 1 {
+		return nil, fmt.Errorf("try: expect at least one param")
+	}
+
+	for i, param := range params {
+		if i == 0 {
+			res, err = apply(param, data)
+		} else {
+			res, err = applyWithVars(apply, param, data, map[string]interface{}{
+				"error": errorToValue(err),
+			})
+		}
+		if err == nil || errors.Is(err, ErrMaxDepthExceeded) || errors.Is(err, context.Canceled) ||
+			errors.Is(err, context.DeadlineExceeded) {
+			return
+		}
+	}
+	return
+}
+
+// errorToValue converts an error to a json object.
+func errorToValue(err error) map[string]interface{} {
+	var thrown *ThrownError
+	if errors.As(err, &thrown) {
+		return map[string]interface{}{
+			"code":    thrown.Code,
+			"message": thrown.Message,
+		}
+	}
+	return map[string]interface{}{
+		"code":    nil,
+		"message": err.Error(),
+	}
+}
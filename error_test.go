@@ -0,0 +1,68 @@
+package jsonlogic
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpTryThrow(t *testing.T) {
+	assert := assert.New(t)
+	jl := NewEmpty()
+	AddOpVar(jl)
+	AddOpDiv(jl)
+	AddOpMap(jl)
+	AddOpVal(jl)
+	AddOpDef(jl)
+	AddOpCall(jl)
+	AddOpTry(jl)
+	AddOpThrow(jl)
+	TestCases{
+		// Throw.
+		{Logic: `{"throw":"x"}`, Data: `null`, Err: true},
+		{Logic: `{"throw":["x","msg"]}`, Data: `null`, Err: true},
+		{Logic: `{"throw":[]}`, Data: `null`, Err: true},
+		{Logic: `{"throw":""}`, Data: `null`, Err: true},
+		{Logic: `{"throw":1}`, Data: `null`, Err: true},
+		// Try.
+		{Logic: `{"try":1}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"try":[{"/":[{"var":"a"},{"var":"b"}]},0]}`, Data: `{"a":1,"b":2}`, Result: float64(0.5)},
+		{Logic: `{"try":[{"/":[{"var":"a"},{"var":"b"}]},0]}`, Data: `{"a":1,"b":0}`, Result: float64(0)},
+		{Logic: `{"try":[{"throw":"a"},{"throw":"b"},{"var":"c"}]}`, Data: `{"c":"C"}`, Result: "C"},
+		{Logic: `{"map":[{"var":"xs"},{"try":[{"/":[1,{"var":""}]},null]}]}`, Data: `{"xs":[1,0,2]}`, Result: []interface{}{float64(1), nil, float64(0.5)}},
+		// Error value.
+		{Logic: `{"try":[{"throw":["x","msg"]},{"val":"error"}]}`, Data: `null`, Result: map[string]interface{}{"code": "x", "message": "msg"}},
+		{Logic: `{"try":[{"throw":"x"},{"val":["error","code"]}]}`, Data: `null`, Result: "x"},
+		{Logic: `{"try":[{"throw":"x"},{"throw":"y"},{"val":["error","code"]}]}`, Data: `null`, Result: "y"},
+		{Logic: `{"try":[{"/":[1,0]},{"val":["error","code"]}]}`, Data: `null`, Result: nil},
+		{Logic: `{"try":[{"/":[1,0]},{"val":["error","message"]}]}`, Data: `null`, Result: "/: got -Inf/+Inf result"},
+		// The binding shadows "error" of data in "val".
+		{Logic: `{"try":[{"throw":"x"},{"val":"error"}]}`, Data: `{"error":"data"}`, Result: map[string]interface{}{"code": "x", "message": ""}},
+		{Logic: `{"try":[{"throw":"x"},{"val":[[0],"error"]}]}`, Data: `{"error":"data"}`, Result: "data"},
+		{Logic: `{"try":[{"throw":"x"},{"var":"error"}]}`, Data: `{"error":"data"}`, Result: "data"},
+		// All fail.
+		{Logic: `{"try":[{"throw":"x"},{"throw":"y"}]}`, Data: `null`, Err: true},
+		{Logic: `{"try":[]}`, Data: `null`, Err: true},
+		// Max depth exceeded is not caught.
+		{Logic: `{"def":[{"f":{"body":{"call":"f"}}},{"try":[{"call":"f"},0]}]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
+
+func TestThrownError(t *testing.T) {
+	assert := assert.New(t)
+	jl := NewEmpty()
+	AddOpThrow(jl)
+	AddOpIf(jl)
+
+	var logic interface{}
+	assert.NoError(json.Unmarshal([]byte(`{"if":[true,{"throw":["out_of_stock","sku 123"]}]}`), &logic))
+	_, err := jl.Apply(logic, nil)
+
+	var thrown *ThrownError
+	assert.True(errors.As(err, &thrown))
+	assert.Equal("out_of_stock", thrown.Code)
+	assert.Equal("sku 123", thrown.Message)
+	assert.Equal("throw: out_of_stock: sku 123", err.Error())
+}
@@ -0,0 +1,288 @@
+package ext
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// AddAggregateOps adds all aggregation operations in this file to the JSONLogic instance:
+// "sum", "avg", "median", "percentile", "stddev", "count_by" and "group_by".
+//
+// The first param of them is an array (null is treated as empty array), and an optional key logic can be
+// evaluated against each item (with jsonlogic.ApplyIter like "map") to get the value to aggregate instead of
+// the item itself. Values are converted by jsonlogic.ToNumeric for numeric aggregations, so null counts as 0
+// like "+", filter them out first if it is not wanted. For example, summing prices of items:
+//   {"sum":[{"var":"items"},{"*":[{"var":"price"},{"var":"qty"}]}]}
+func AddAggregateOps(jl *jsonlogic.JSONLogic) {
+	AddOpSum(jl)
+	AddOpAvg(jl)
+	AddOpMedian(jl)
+	AddOpPercentile(jl)
+	AddOpStddev(jl)
+	AddOpCountBy(jl)
+	AddOpGroupBy(jl)
+}
+
+// applyNumericKeys evaluates the array (params[0]) and key logic (params[keyIndex] if exists) then converts
+// keys to numerics.
+func applyNumericKeys(name string, keyIndex int, apply jsonlogic.Applier, params []interface{}, data interface{}) ([]float64, error) {
+	arr, err := applyArray(name, keyIndex, keyIndex+1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	var logic interface{}
+	if len(params) > keyIndex {
+		logic = params[keyIndex]
+	}
+	keys, err := applyKeys(apply, logic, arr)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]float64, 0, len(keys))
+	for _, key := range keys {
+		n, err := jsonlogic.ToNumeric(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		ret = append(ret, n)
+	}
+	return ret, nil
+}
+
+func sum(ns []float64) float64 {
+	s := float64(0)
+	for _, n := range ns {
+		s += n
+	}
+	return s
+}
+
+// percentile returns the p-th (0-100) percentile of sorted ns using linear interpolation between closest
+// ranks, the same as Excel's PERCENTILE.INC or numpy's default.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := math.Floor(rank)
+	hi := math.Ceil(rank)
+	if lo == hi {
+		return sorted[int(lo)]
+	}
+	return sorted[int(lo)] + (sorted[int(hi)]-sorted[int(lo)])*(rank-lo)
+}
+
+// AddOpSum adds "sum" operation to the JSONLogic instance, an empty array sums to 0:
+//   - {"sum":[[1,2,3]]} -> 6
+//   - {"sum":[{"var":"items"},{"var":"price"}]} -> total price of items
+func AddOpSum(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("sum", opSum)
+}
+
+func opSum(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ns, err := applyNumericKeys("sum", 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return numericResult("sum", sum(ns))
+}
+
+// AddOpAvg adds "avg" operation to the JSONLogic instance, which returns the arithmetic mean, or null for
+// an empty array:
+//   - {"avg":[[1,2,3,4]]} -> 2.5
+func AddOpAvg(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("avg", opAvg)
+}
+
+func opAvg(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ns, err := applyNumericKeys("avg", 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(ns) == 0 {
+		return nil, nil
+	}
+	return numericResult("avg", sum(ns)/float64(len(ns)))
+}
+
+// AddOpMedian adds "median" operation to the JSONLogic instance, which returns the middle value (or the mean
+// of the two middle values), or null for an empty array:
+//   - {"median":[[3,1,2]]} -> 2
+//   - {"median":[[4,1,3,2]]} -> 2.5
+func AddOpMedian(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("median", opMedian)
+}
+
+func opMedian(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ns, err := applyNumericKeys("median", 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(ns) == 0 {
+		return nil, nil
+	}
+	sort.Float64s(ns)
+	return percentile(ns, 50), nil
+}
+
+// AddOpPercentile adds "percentile" operation to the JSONLogic instance. Params are the array, the
+// percentile in [0, 100] and the optional key logic. It interpolates linearly between closest ranks (like
+// Excel's PERCENTILE.INC), and returns null for an empty array:
+//   - {"percentile":[[1,2,3,4,5],90]} -> 4.6
+//   - {"percentile":[{"var":"requests"},99,{"var":"latency"}]} -> p99 latency
+func AddOpPercentile(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("percentile", opPercentile)
+}
+
+func opPercentile(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	if err := checkParams("percentile", params, 2, 3); err != nil {
+		return nil, err
+	}
+	r, err := apply(params[1], data)
+	if err != nil {
+		return nil, err
+	}
+	p, err := jsonlogic.ToNumeric(r)
+	if err != nil {
+		return nil, fmt.Errorf("percentile: %s", err.Error())
+	}
+	if p < 0 || p > 100 {
+		return nil, fmt.Errorf("percentile: expect percentile in [0, 100] but got %v", p)
+	}
+
+	// Move key logic (if any) to the second place.
+	args := []interface{}{params[0]}
+	if len(params) > 2 {
+		args = append(args, params[2])
+	}
+	ns, err := applyNumericKeys("percentile", 1, apply, args, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(ns) == 0 {
+		return nil, nil
+	}
+	sort.Float64s(ns)
+	return percentile(ns, p), nil
+}
+
+// AddOpStddev adds "stddev" operation to the JSONLogic instance. Params are the array, the optional key
+// logic and the optional kind: "population" (default) or "sample". It returns null if there are not enough
+// items (none for population, less than two for sample):
+//   - {"stddev":[[2,4,4,4,5,5,7,9]]} -> 2
+//   - {"stddev":[[1,2,3,4],null,"sample"]} -> 1.2909944487358056
+func AddOpStddev(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("stddev", opStddev)
+}
+
+func opStddev(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	if err := checkParams("stddev", params, 1, 3); err != nil {
+		return nil, err
+	}
+	sample := false
+	if len(params) > 2 {
+		r, err := apply(params[2], data)
+		if err != nil {
+			return nil, err
+		}
+		switch r {
+		case "population":
+		case "sample":
+			sample = true
+		default:
+			return nil, fmt.Errorf("stddev: expect \"population\" or \"sample\" but got %v", r)
+		}
+		params = params[:2]
+	}
+	ns, err := applyNumericKeys("stddev", 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+
+	n := float64(len(ns))
+	if sample {
+		n--
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+	mean := sum(ns) / float64(len(ns))
+	variance := float64(0)
+	for _, x := range ns {
+		variance += (x - mean) * (x - mean)
+	}
+	return numericResult("stddev", math.Sqrt(variance/n))
+}
+
+// applyGroupKeys evaluates the array and the key logic for "count_by"/"group_by", keys are converted by
+// jsonlogic.ToString.
+func applyGroupKeys(name string, apply jsonlogic.Applier, params []interface{}, data interface{}) ([]interface{}, []string, error) {
+	arr, err := applyArray(name, 1, 2, apply, params, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var logic interface{}
+	if len(params) > 1 {
+		logic = params[1]
+	}
+	keys, err := applyKeys(apply, logic, arr)
+	if err != nil {
+		return nil, nil, err
+	}
+	ret := make([]string, 0, len(keys))
+	for _, key := range keys {
+		s, err := jsonlogic.ToString(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		ret = append(ret, s)
+	}
+	return arr, ret, nil
+}
+
+// AddOpCountBy adds "count_by" operation to the JSONLogic instance, which returns an object mapping keys
+// (converted by jsonlogic.ToString) to the number of items having them:
+//   - {"count_by":[["a","b","a"]]} -> {"a":2,"b":1}
+//   - {"count_by":[{"var":"orders"},{"var":"status"}]} -> {"paid":3,"refunded":1}
+func AddOpCountBy(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("count_by", opCountBy)
+}
+
+func opCountBy(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	_, keys, err := applyGroupKeys("count_by", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, key := range keys {
+		counts[key]++
+	}
+	ret := make(map[string]interface{}, len(counts))
+	for key, n := range counts {
+		ret[key] = float64(n)
+	}
+	return ret, nil
+}
+
+// AddOpGroupBy adds "group_by" operation to the JSONLogic instance, which returns an object mapping keys
+// (converted by jsonlogic.ToString) to arrays of items having them, in their original order:
+//   - {"group_by":[[1,2,3,4],{"%":[{"var":""},2]}]} -> {"1":[1,3],"0":[2,4]}
+func AddOpGroupBy(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("group_by", opGroupBy)
+}
+
+func opGroupBy(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	arr, keys, err := applyGroupKeys("group_by", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string][]interface{})
+	for i, key := range keys {
+		groups[key] = append(groups[key], arr[i])
+	}
+	ret := make(map[string]interface{}, len(groups))
+	for key, items := range groups {
+		ret[key] = items
+	}
+	return ret, nil
+}
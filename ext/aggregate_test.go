@@ -0,0 +1,66 @@
+package ext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestAggregateOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+	jsonlogic.AddOpVal(jl)
+	AddAggregateOps(jl)
+	jsonlogic.TestCases{
+		// sum.
+		{Logic: `{"sum":[[1,2,3]]}`, Data: `null`, Result: float64(6)},
+		{Logic: `{"sum":[[1,"2",null,true]]}`, Data: `null`, Result: float64(4)},
+		{Logic: `{"sum":[[]]}`, Data: `null`, Result: float64(0)},
+		{Logic: `{"sum":{"var":"missing"}}`, Data: `{}`, Result: float64(0)},
+		{Logic: `{"sum":[{"var":"items"},{"*":[{"var":"price"},{"var":"qty"}]}]}`, Data: `{"items":[{"price":2,"qty":3},{"price":1.5,"qty":2}]}`, Result: float64(9)},
+		{Logic: `{"sum":[{"var":"items"},{"*":[{"var":"price"},{"val":[[1],"rate"]}]}]}`, Data: `{"rate":2,"items":[{"price":2},{"price":3}]}`, Result: float64(10)},
+		{Logic: `{"sum":[[1e308,1e308]]}`, Data: `null`, Err: true},
+		{Logic: `{"sum":[[1,"x"]]}`, Data: `null`, Err: true},
+		{Logic: `{"sum":[[[1]]]}`, Data: `null`, Err: true},
+		{Logic: `{"sum":["abc"]}`, Data: `null`, Err: true},
+		// avg.
+		{Logic: `{"avg":[[1,2,3,4]]}`, Data: `null`, Result: float64(2.5)},
+		{Logic: `{"avg":[[]]}`, Data: `null`, Result: nil},
+		{Logic: `{"avg":[{"var":"users"},{"var":"age"}]}`, Data: `{"users":[{"age":20},{"age":30}]}`, Result: float64(25)},
+		// median.
+		{Logic: `{"median":[[3,1,2]]}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"median":[[4,1,3,2]]}`, Data: `null`, Result: float64(2.5)},
+		{Logic: `{"median":[[]]}`, Data: `null`, Result: nil},
+		// percentile.
+		{Logic: `{"percentile":[[1,2,3,4,5],90]}`, Data: `null`, Result: float64(4.6)},
+		{Logic: `{"percentile":[[5,1,3],0]}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"percentile":[[5,1,3],100]}`, Data: `null`, Result: float64(5)},
+		{Logic: `{"percentile":[[7],50]}`, Data: `null`, Result: float64(7)},
+		{Logic: `{"percentile":[{"var":"reqs"},50,{"var":"ms"}]}`, Data: `{"reqs":[{"ms":10},{"ms":30},{"ms":20}]}`, Result: float64(20)},
+		{Logic: `{"percentile":[[],50]}`, Data: `null`, Result: nil},
+		{Logic: `{"percentile":[[1,2],101]}`, Data: `null`, Err: true},
+		{Logic: `{"percentile":[[1,2]]}`, Data: `null`, Err: true},
+		// stddev.
+		{Logic: `{"stddev":[[2,4,4,4,5,5,7,9]]}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"stddev":[[1,2,3,4],null,"sample"]}`, Data: `null`, Result: float64(1.2909944487358056)},
+		{Logic: `{"stddev":[[1],null,"sample"]}`, Data: `null`, Result: nil},
+		{Logic: `{"stddev":[[]]}`, Data: `null`, Result: nil},
+		{Logic: `{"stddev":[[1,2],null,"x"]}`, Data: `null`, Err: true},
+		// count_by.
+		{Logic: `{"count_by":[["a","b","a"]]}`, Data: `null`, Result: map[string]interface{}{"a": float64(2), "b": float64(1)}},
+		{Logic: `{"count_by":[{"var":"orders"},{"var":"status"}]}`, Data: `{"orders":[{"status":"paid"},{"status":"paid"},{}]}`, Result: map[string]interface{}{"paid": float64(2), "null": float64(1)}},
+		{Logic: `{"count_by":[[]]}`, Data: `null`, Result: map[string]interface{}{}},
+		{Logic: `{"count_by":[[[1]]]}`, Data: `null`, Err: true},
+		// group_by.
+		{Logic: `{"group_by":[[1,2,3,4],{"%":[{"var":""},2]}]}`, Data: `null`, Result: map[string]interface{}{
+			"1": []interface{}{float64(1), float64(3)},
+			"0": []interface{}{float64(2), float64(4)},
+		}},
+		{Logic: `{"group_by":[{"var":"users"},{"var":"team"}]}`, Data: `{"users":[{"n":"a","team":"x"},{"n":"b","team":"y"},{"n":"c","team":"x"}]}`, Result: map[string]interface{}{
+			"x": []interface{}{map[string]interface{}{"n": "a", "team": "x"}, map[string]interface{}{"n": "c", "team": "x"}},
+			"y": []interface{}{map[string]interface{}{"n": "b", "team": "y"}},
+		}},
+	}.Run(assert, jl)
+}
@@ -0,0 +1,576 @@
+package ext
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// AddArrayOps adds all array operations in this file to the JSONLogic instance:
+// "length" (see AddOpLength), "sort", "unique", "flatten", "slice", "reverse", "find", "find_index",
+// "index_of", "first", "last", "count" and "zip".
+//
+// Like "map"/"filter"/..., logic params (e.g. key of "sort" or predicate of "find") are evaluated against
+// each item with jsonlogic.ApplyIter, so they can still reach outer data and the current index by "val"
+// and "index". Array params evaluated to null are treated as empty arrays, other non-arrays are errors.
+func AddArrayOps(jl *jsonlogic.JSONLogic) {
+	AddOpLength(jl)
+	AddOpSort(jl)
+	AddOpUnique(jl)
+	AddOpFlatten(jl)
+	AddOpSlice(jl)
+	AddOpReverse(jl)
+	AddOpFind(jl)
+	AddOpFindIndex(jl)
+	AddOpIndexOf(jl)
+	AddOpFirst(jl)
+	AddOpLast(jl)
+	AddOpCount(jl)
+	AddOpZip(jl)
+}
+
+// toArray converts an evaluated param to array, null is treated as empty array.
+func toArray(name string, obj interface{}) ([]interface{}, error) {
+	switch o := obj.(type) {
+	case nil:
+		return []interface{}{}, nil
+	case []interface{}:
+		return o, nil
+	default:
+		return nil, fmt.Errorf("%s: expect array but got %T", name, obj)
+	}
+}
+
+// applyArray checks the number of params and evaluates the first one to array. Other params are not evaluated.
+func applyArray(name string, min, max int, apply jsonlogic.Applier, params []interface{}, data interface{}) ([]interface{}, error) {
+	if err := checkParams(name, params, min, max); err != nil {
+		return nil, err
+	}
+	r, err := apply(params[0], data)
+	if err != nil {
+		return nil, err
+	}
+	return toArray(name, r)
+}
+
+// applyKeys evaluates key logic against each item of arr. If logic is nil, items themselves are returned.
+func applyKeys(apply jsonlogic.Applier, logic interface{}, arr []interface{}) ([]interface{}, error) {
+	if logic == nil {
+		return arr, nil
+	}
+	keys := make([]interface{}, 0, len(arr))
+	for i, item := range arr {
+		key, err := jsonlogic.ApplyIter(apply, logic, item, i)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// compareKeys compares two keys of the same type: numbers, strings (in bytes order) or booleans (false first).
+// Nulls are less than any other keys.
+func compareKeys(a, b interface{}) (int, error) {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0, nil
+		case a == nil:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	}
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1, nil
+			case x > y:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0, nil
+			case y:
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, fmt.Errorf("can not compare %T with %T", a, b)
+}
+
+// valueKey returns a string key identifying a json value, deeply equal values have the same key.
+func valueKey(obj interface{}) string {
+	return canonicalKey(obj, false)
+}
+
+// valueEqual reports whether two json values are deeply equal.
+func valueEqual(a, b interface{}) bool {
+	return valueKey(a) == valueKey(b)
+}
+
+// toIndex converts param to integer index.
+func toIndex(name string, obj interface{}) (int, error) {
+	f, err := jsonlogic.ToNumeric(obj)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s", name, err.Error())
+	}
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("%s: expect integer but got %v", name, f)
+	}
+	if f > math.MaxInt32 {
+		return math.MaxInt32, nil
+	}
+	if f < math.MinInt32 {
+		return math.MinInt32, nil
+	}
+	return int(f), nil
+}
+
+// AddOpLength adds "length" operation to the JSONLogic instance, which returns the number of items of
+// an array, or the number of characters of other values (converted by jsonlogic.ToString):
+//   - {"length":"你好"} -> 2
+//   - {"length":[[1,2,3]]} -> 3
+func AddOpLength(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("length", opLength)
+}
+
+func opLength(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	if err := checkParams("length", params, 1, 1); err != nil {
+		return nil, err
+	}
+	r, err := apply(params[0], data)
+	if err != nil {
+		return nil, err
+	}
+	if arr, ok := r.([]interface{}); ok {
+		return float64(len(arr)), nil
+	}
+	s, err := jsonlogic.ToString(r)
+	if err != nil {
+		return nil, fmt.Errorf("length: %s", err.Error())
+	}
+	return float64(utf8.RuneCountInString(s)), nil
+}
+
+// AddOpSort adds "sort" operation to the JSONLogic instance. Params are the array, optional key logic
+// evaluated against each item (null means the item itself) and optional direction "asc" (default) or "desc".
+// Keys must be all numbers, all strings or all booleans, nulls go first in ascending order. Sorting is
+// stable:
+//   - {"sort":[[3,1,2]]} -> [1,2,3]
+//   - {"sort":[{"var":"items"},{"var":"price"},"desc"]} -> items sorted by price in descending order
+func AddOpSort(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("sort", opSort)
+}
+
+func opSort(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	arr, err := applyArray("sort", 1, 3, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	desc := false
+	if len(params) > 2 {
+		r, err := apply(params[2], data)
+		if err != nil {
+			return nil, err
+		}
+		switch r {
+		case "asc":
+		case "desc":
+			desc = true
+		default:
+			return nil, fmt.Errorf("sort: expect \"asc\" or \"desc\" as direction but got %v", r)
+		}
+	}
+	var logic interface{}
+	if len(params) > 1 {
+		logic = params[1]
+	}
+	keys, err := applyKeys(apply, logic, arr)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := make([]int, len(arr))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		var c int
+		c, err = compareKeys(keys[idx[i]], keys[idx[j]])
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sort: %s", err.Error())
+	}
+
+	ret := make([]interface{}, 0, len(arr))
+	for _, i := range idx {
+		ret = append(ret, arr[i])
+	}
+	return ret, nil
+}
+
+// AddOpUnique adds "unique" operation to the JSONLogic instance, which removes duplicated (deeply equal)
+// items, keeping the first ones. An optional key logic evaluated against each item is used to compare
+// instead of the item itself:
+//   - {"unique":[[1,2,1,3,2]]} -> [1,2,3]
+//   - {"unique":[{"var":"users"},{"var":"email"}]} -> users with distinct emails
+func AddOpUnique(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("unique", opUnique)
+}
+
+func opUnique(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	arr, err := applyArray("unique", 1, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	var logic interface{}
+	if len(params) > 1 {
+		logic = params[1]
+	}
+	keys, err := applyKeys(apply, logic, arr)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(arr))
+	ret := []interface{}{}
+	for i, item := range arr {
+		k := valueKey(keys[i])
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		ret = append(ret, item)
+	}
+	return ret, nil
+}
+
+// AddOpFlatten adds "flatten" operation to the JSONLogic instance, which flattens nested arrays up to
+// the optional depth (default 1):
+//   - {"flatten":[[1,[2,[3]]]]} -> [1,2,[3]]
+//   - {"flatten":[[1,[2,[3]]],2]} -> [1,2,3]
+func AddOpFlatten(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("flatten", opFlatten)
+}
+
+func opFlatten(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	arr, err := applyArray("flatten", 1, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	depth := 1
+	if len(params) > 1 {
+		r, err := apply(params[1], data)
+		if err != nil {
+			return nil, err
+		}
+		depth, err = toIndex("flatten", r)
+		if err != nil {
+			return nil, err
+		}
+		if depth < 0 {
+			return nil, fmt.Errorf("flatten: expect non-negative depth but got %d", depth)
+		}
+	}
+	return flatten([]interface{}{}, arr, depth), nil
+}
+
+func flatten(dst, arr []interface{}, depth int) []interface{} {
+	for _, item := range arr {
+		if sub, ok := item.([]interface{}); ok && depth > 0 {
+			dst = flatten(dst, sub, depth-1)
+			continue
+		}
+		dst = append(dst, item)
+	}
+	return dst
+}
+
+// AddOpSlice adds "slice" operation to the JSONLogic instance, which returns items in [start, end) like
+// JavaScript's Array.prototype.slice, negative positions count from the end:
+//   - {"slice":[[1,2,3,4],1]} -> [2,3,4]
+//   - {"slice":[[1,2,3,4],1,-1]} -> [2,3]
+func AddOpSlice(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("slice", opSlice)
+}
+
+func opSlice(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, err := applyN("slice", apply, params, data, 2, 3)
+	if err != nil {
+		return nil, err
+	}
+	arr, err := toArray("slice", params[0])
+	if err != nil {
+		return nil, err
+	}
+
+	bound := func(obj interface{}) (int, error) {
+		i, err := toIndex("slice", obj)
+		if err != nil {
+			return 0, err
+		}
+		if i < 0 {
+			i += len(arr)
+			if i < 0 {
+				i = 0
+			}
+		}
+		if i > len(arr) {
+			i = len(arr)
+		}
+		return i, nil
+	}
+	start, err := bound(params[1])
+	if err != nil {
+		return nil, err
+	}
+	end := len(arr)
+	if len(params) > 2 {
+		end, err = bound(params[2])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ret := []interface{}{}
+	if start < end {
+		ret = append(ret, arr[start:end]...)
+	}
+	return ret, nil
+}
+
+// AddOpReverse adds "reverse" operation to the JSONLogic instance:
+//   - {"reverse":[[1,2,3]]} -> [3,2,1]
+func AddOpReverse(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("reverse", opReverse)
+}
+
+func opReverse(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	arr, err := applyArray("reverse", 1, 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]interface{}, 0, len(arr))
+	for i := len(arr) - 1; i >= 0; i-- {
+		ret = append(ret, arr[i])
+	}
+	return ret, nil
+}
+
+// findIndex returns the index of the first item in the array (the first param) that the logic (the second
+// param) evaluates to truthy, or -1 if not found.
+func findIndex(name string, apply jsonlogic.Applier, params []interface{}, data interface{}) ([]interface{}, int, error) {
+	arr, err := applyArray(name, 2, 2, apply, params, data)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, item := range arr {
+		r, err := jsonlogic.ApplyIter(apply, params[1], item, i)
+		if err != nil {
+			return nil, 0, err
+		}
+		if jsonlogic.ToBool(r) {
+			return arr, i, nil
+		}
+	}
+	return arr, -1, nil
+}
+
+// AddOpFind adds "find" operation to the JSONLogic instance, which returns the first item that the logic
+// evaluates to truthy, or null if not found:
+//   - {"find":[{"var":"users"},{"===":[{"var":"id"},2]}]} -> the user with id 2
+func AddOpFind(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("find", opFind)
+}
+
+func opFind(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	arr, i, err := findIndex("find", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 {
+		return nil, nil
+	}
+	return arr[i], nil
+}
+
+// AddOpFindIndex adds "find_index" operation to the JSONLogic instance, which is the same as "find" but
+// returns the index, or -1 if not found:
+//   - {"find_index":[[1,5,10],{">":[{"var":""},3]}]} -> 1
+func AddOpFindIndex(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("find_index", opFindIndex)
+}
+
+func opFindIndex(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	_, i, err := findIndex("find_index", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return float64(i), nil
+}
+
+// AddOpIndexOf adds "index_of" operation to the JSONLogic instance, which returns the index of the first
+// item deeply equal to the value in an array, or the character position of a substring in a string,
+// -1 if not found:
+//   - {"index_of":[["a","b"],"b"]} -> 1
+//   - {"index_of":[[[1],[2]],[2]]} -> 1
+//   - {"index_of":["你好","好"]} -> 1
+func AddOpIndexOf(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("index_of", opIndexOf)
+}
+
+func opIndexOf(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, err := applyN("index_of", apply, params, data, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := params[0].(string); ok {
+		sub, err := jsonlogic.ToString(params[1])
+		if err != nil {
+			return nil, fmt.Errorf("index_of: %s", err.Error())
+		}
+		i := strings.Index(s, sub)
+		if i < 0 {
+			return float64(-1), nil
+		}
+		return float64(utf8.RuneCountInString(s[:i])), nil
+	}
+
+	arr, err := toArray("index_of", params[0])
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range arr {
+		if valueEqual(item, params[1]) {
+			return float64(i), nil
+		}
+	}
+	return float64(-1), nil
+}
+
+// AddOpFirst adds "first" operation to the JSONLogic instance, which returns the first item, or null if
+// the array is empty:
+//   - {"first":[[1,2,3]]} -> 1
+func AddOpFirst(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("first", opFirst)
+}
+
+func opFirst(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	arr, err := applyArray("first", 1, 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(arr) == 0 {
+		return nil, nil
+	}
+	return arr[0], nil
+}
+
+// AddOpLast adds "last" operation to the JSONLogic instance, which returns the last item, or null if
+// the array is empty:
+//   - {"last":[[1,2,3]]} -> 3
+func AddOpLast(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("last", opLast)
+}
+
+func opLast(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	arr, err := applyArray("last", 1, 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(arr) == 0 {
+		return nil, nil
+	}
+	return arr[len(arr)-1], nil
+}
+
+// AddOpCount adds "count" operation to the JSONLogic instance, which returns the number of items that
+// the optional logic evaluates to truthy, or the number of all items without logic:
+//   - {"count":[[1,2,3]]} -> 3
+//   - {"count":[[1,2,3],{">":[{"var":""},1]}]} -> 2
+func AddOpCount(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("count", opCount)
+}
+
+func opCount(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	arr, err := applyArray("count", 1, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) < 2 {
+		return float64(len(arr)), nil
+	}
+	n := 0
+	for i, item := range arr {
+		r, err := jsonlogic.ApplyIter(apply, params[1], item, i)
+		if err != nil {
+			return nil, err
+		}
+		if jsonlogic.ToBool(r) {
+			n++
+		}
+	}
+	return float64(n), nil
+}
+
+// AddOpZip adds "zip" operation to the JSONLogic instance, which groups items at the same position of
+// arrays, the result is as long as the shortest array:
+//   - {"zip":[["a","b","c"],[1,2]]} -> [["a",1],["b",2]]
+func AddOpZip(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("zip", opZip)
+}
+
+func opZip(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, err := applyN("zip", apply, params, data, 1, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	arrs := make([][]interface{}, 0, len(params))
+	n := -1
+	for _, param := range params {
+		arr, err := toArray("zip", param)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || len(arr) < n {
+			n = len(arr)
+		}
+		arrs = append(arrs, arr)
+	}
+
+	ret := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		tuple := make([]interface{}, 0, len(arrs))
+		for _, arr := range arrs {
+			tuple = append(tuple, arr[i])
+		}
+		ret = append(ret, tuple)
+	}
+	return ret, nil
+}
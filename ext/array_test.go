@@ -0,0 +1,94 @@
+package ext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestArrayOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+	jsonlogic.AddOpVal(jl)
+	jsonlogic.AddOpIndex(jl)
+	AddArrayOps(jl)
+	jsonlogic.TestCases{
+		// length.
+		{Logic: `{"length":[[1,2,3]]}`, Data: `null`, Result: float64(3)},
+		{Logic: `{"length":{"var":"a"}}`, Data: `{"a":[]}`, Result: float64(0)},
+		{Logic: `{"length":"你好"}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"length":[{}]}`, Data: `null`, Err: true},
+		// sort.
+		{Logic: `{"sort":[[3,1,2]]}`, Data: `null`, Result: []interface{}{float64(1), float64(2), float64(3)}},
+		{Logic: `{"sort":[[3,1,2],null,"desc"]}`, Data: `null`, Result: []interface{}{float64(3), float64(2), float64(1)}},
+		{Logic: `{"sort":[["b",null,"a"]]}`, Data: `null`, Result: []interface{}{nil, "a", "b"}},
+		{Logic: `{"sort":[[true,false]]}`, Data: `null`, Result: []interface{}{false, true}},
+		{Logic: `{"sort":[{"var":"items"},{"var":"p"},"desc"]}`, Data: `{"items":[{"id":1,"p":5},{"id":2,"p":9},{"id":3,"p":5}]}`, Result: []interface{}{
+			map[string]interface{}{"id": float64(2), "p": float64(9)},
+			map[string]interface{}{"id": float64(1), "p": float64(5)},
+			map[string]interface{}{"id": float64(3), "p": float64(5)},
+		}},
+		{Logic: `{"sort":[["a","b"],{"index":[]},"desc"]}`, Data: `null`, Result: []interface{}{"b", "a"}},
+		{Logic: `{"sort":[null]}`, Data: `null`, Result: []interface{}{}},
+		{Logic: `{"sort":[[1,"a"]]}`, Data: `null`, Err: true},
+		{Logic: `{"sort":[[[1],[2]]]}`, Data: `null`, Err: true},
+		{Logic: `{"sort":[[1,2],null,"up"]}`, Data: `null`, Err: true},
+		{Logic: `{"sort":["abc"]}`, Data: `null`, Err: true},
+		// unique.
+		{Logic: `{"unique":[[1,2,1,3,2]]}`, Data: `null`, Result: []interface{}{float64(1), float64(2), float64(3)}},
+		{Logic: `{"unique":[[{"a":1,"b":2},{"b":2,"a":1},[1],[1],"1"]]}`, Data: `null`, Result: []interface{}{
+			map[string]interface{}{"a": float64(1), "b": float64(2)}, []interface{}{float64(1)}, "1",
+		}},
+		{Logic: `{"unique":[{"var":"users"},{"var":"email"}]}`, Data: `{"users":[{"id":1,"email":"a"},{"id":2,"email":"a"},{"id":3,"email":"b"}]}`, Result: []interface{}{
+			map[string]interface{}{"id": float64(1), "email": "a"},
+			map[string]interface{}{"id": float64(3), "email": "b"},
+		}},
+		// flatten.
+		{Logic: `{"flatten":[[1,[2,[3]]]]}`, Data: `null`, Result: []interface{}{float64(1), float64(2), []interface{}{float64(3)}}},
+		{Logic: `{"flatten":[[1,[2,[3]]],2]}`, Data: `null`, Result: []interface{}{float64(1), float64(2), float64(3)}},
+		{Logic: `{"flatten":[[1,[2]],0]}`, Data: `null`, Result: []interface{}{float64(1), []interface{}{float64(2)}}},
+		{Logic: `{"flatten":[[1],-1]}`, Data: `null`, Err: true},
+		{Logic: `{"flatten":[[1],1.5]}`, Data: `null`, Err: true},
+		// slice.
+		{Logic: `{"slice":[[1,2,3,4],1]}`, Data: `null`, Result: []interface{}{float64(2), float64(3), float64(4)}},
+		{Logic: `{"slice":[[1,2,3,4],1,-1]}`, Data: `null`, Result: []interface{}{float64(2), float64(3)}},
+		{Logic: `{"slice":[[1,2,3,4],-2]}`, Data: `null`, Result: []interface{}{float64(3), float64(4)}},
+		{Logic: `{"slice":[[1,2,3,4],-10,10]}`, Data: `null`, Result: []interface{}{float64(1), float64(2), float64(3), float64(4)}},
+		{Logic: `{"slice":[[1,2,3,4],3,1]}`, Data: `null`, Result: []interface{}{}},
+		{Logic: `{"slice":[[1,2]]}`, Data: `null`, Err: true},
+		// reverse.
+		{Logic: `{"reverse":[[1,2,3]]}`, Data: `null`, Result: []interface{}{float64(3), float64(2), float64(1)}},
+		{Logic: `{"reverse":[[]]}`, Data: `null`, Result: []interface{}{}},
+		// find/find_index.
+		{Logic: `{"find":[{"var":"users"},{"===":[{"var":"id"},2]}]}`, Data: `{"users":[{"id":1},{"id":2}]}`, Result: map[string]interface{}{"id": float64(2)}},
+		{Logic: `{"find":[{"var":"users"},{"===":[{"var":"id"},{"val":[[1],"id"]}]}]}`, Data: `{"id":1,"users":[{"id":1},{"id":2}]}`, Result: map[string]interface{}{"id": float64(1)}},
+		{Logic: `{"find":[[1,2],{">":[{"var":""},5]}]}`, Data: `null`, Result: nil},
+		{Logic: `{"find":[[1,2]]}`, Data: `null`, Err: true},
+		{Logic: `{"find_index":[[1,5,10],{">":[{"var":""},3]}]}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"find_index":[[1,5,10],{">":[{"var":""},30]}]}`, Data: `null`, Result: float64(-1)},
+		// index_of.
+		{Logic: `{"index_of":[["a","b"],"b"]}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"index_of":[[[1],[2]],[2]]}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"index_of":[[1,2],"2"]}`, Data: `null`, Result: float64(-1)},
+		{Logic: `{"index_of":["你好","好"]}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"index_of":["abc","d"]}`, Data: `null`, Result: float64(-1)},
+		{Logic: `{"index_of":[1,1]}`, Data: `null`, Err: true},
+		// first/last.
+		{Logic: `{"first":[[1,2,3]]}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"last":[[1,2,3]]}`, Data: `null`, Result: float64(3)},
+		{Logic: `{"first":[[]]}`, Data: `null`, Result: nil},
+		{Logic: `{"last":{"var":"missing"}}`, Data: `{}`, Result: nil},
+		// count.
+		{Logic: `{"count":[[1,2,3]]}`, Data: `null`, Result: float64(3)},
+		{Logic: `{"count":[[1,2,3],{">":[{"var":""},1]}]}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"count":[[1,2,3],{">":[{"index":[]},0]}]}`, Data: `null`, Result: float64(2)},
+		// zip.
+		{Logic: `{"zip":[["a","b","c"],[1,2]]}`, Data: `null`, Result: []interface{}{
+			[]interface{}{"a", float64(1)}, []interface{}{"b", float64(2)},
+		}},
+		{Logic: `{"zip":[[1,2],[]]}`, Data: `null`, Result: []interface{}{}},
+		{Logic: `{"zip":[[1,2],"ab"]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
@@ -0,0 +1,379 @@
+package ext
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// AddDatetimeOps adds all date and time operations in this file to the JSONLogic instance:
+// "datetime", "now", "date_add", "date_diff", "date_before", "date_after", "date_part" and "date_tz".
+//
+// There is no date type in json, so date times are represented as RFC 3339 strings (e.g. "2020-01-02T15:04:05Z"
+// or "2020-01-02T23:04:05+08:00"). As inputs, dates ("2020-01-02", midnight in UTC) and numbers (unix
+// timestamps in seconds) are also accepted. Results are always RFC 3339 strings with optional fractional seconds.
+//
+// "now" reads the clock of the JSONLogic instance (see jsonlogic.JSONLogic.SetClock), so time can be frozen
+// in tests.
+func AddDatetimeOps(jl *jsonlogic.JSONLogic) {
+	AddOpDatetime(jl)
+	AddOpNow(jl)
+	AddOpDateAdd(jl)
+	AddOpDateDiff(jl)
+	AddOpDateBefore(jl)
+	AddOpDateAfter(jl)
+	AddOpDatePart(jl)
+	AddOpDateTz(jl)
+}
+
+// Unix timestamps of "0001-01-01T00:00:00Z" and "9999-12-31T23:59:59Z", the range of years in RFC 3339.
+const (
+	minUnixSeconds = -62135596800
+	maxUnixSeconds = 253402300799
+)
+
+// ToTime converts a json value to time: an RFC 3339 string, a date string "2006-01-02" (in UTC) or
+// a number of unix timestamp in seconds (in UTC) between year 0001 and 9999.
+func ToTime(obj interface{}) (time.Time, error) {
+	switch o := obj.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, o); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse("2006-01-02", o); err == nil {
+			return t, nil
+		}
+		return time.Time{}, fmt.Errorf("ToTime got invalid date time %q", o)
+	case float64:
+		if math.IsNaN(o) || math.IsInf(o, 0) {
+			return time.Time{}, fmt.Errorf("ToTime got NaN/+Inf/-Inf")
+		}
+		if o < minUnixSeconds || o >= maxUnixSeconds+1 {
+			return time.Time{}, fmt.Errorf("ToTime got out of range unix timestamp %v", o)
+		}
+		sec, frac := math.Modf(o)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("ToTime not support type %T", obj)
+	}
+}
+
+// formatTime formats time as RFC 3339 string.
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// locations caches loaded locations.
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q", name)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// applyTimes checks the number of params, evaluates them and converts the first n ones to time.
+func applyTimes(name string, n, min, max int, apply jsonlogic.Applier, params []interface{}, data interface{}) ([]time.Time, []interface{}, error) {
+	params, err := applyN(name, apply, params, data, min, max)
+	if err != nil {
+		return nil, nil, err
+	}
+	ts := make([]time.Time, 0, n)
+	for _, param := range params[:n] {
+		t, err := ToTime(param)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		ts = append(ts, t)
+	}
+	return ts, params, nil
+}
+
+// AddOpDatetime adds "datetime" operation to the JSONLogic instance, which parses and normalizes a date time
+// to RFC 3339 string (see ToTime):
+//   - {"datetime":"2020-01-02"} -> "2020-01-02T00:00:00Z"
+//   - {"datetime":0} -> "1970-01-01T00:00:00Z"
+func AddOpDatetime(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("datetime", opDatetime)
+}
+
+func opDatetime(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ts, _, err := applyTimes("datetime", 1, 1, 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return formatTime(ts[0]), nil
+}
+
+// AddOpNow adds "now" operation to the JSONLogic instance, which returns the current time in UTC from
+// the clock of the JSONLogic instance:
+//   - {"now":[]} -> "2020-01-02T15:04:05.123Z"
+func AddOpNow(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("now", opNow)
+}
+
+func opNow(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	if len(params) != 0 && !(len(params) == 1 && params[0] == nil) {
+		return nil, fmt.Errorf("now: expect no param")
+	}
+	return formatTime(jsonlogic.Now(apply).UTC()), nil
+}
+
+// Units of date time used by "date_add" and "date_diff".
+var durationUnits = map[string]time.Duration{
+	"week":        7 * 24 * time.Hour,
+	"day":         24 * time.Hour,
+	"hour":        time.Hour,
+	"minute":      time.Minute,
+	"second":      time.Second,
+	"millisecond": time.Millisecond,
+}
+
+// parseDuration parses Go duration string (e.g. "1h30m") with extra "d" (day) and "w" (week) units.
+func parseDuration(s string) (time.Duration, error) {
+	orig := s
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign, s = -1, s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	var days float64
+	for _, unit := range []struct {
+		suffix string
+		days   float64
+	}{
+		{"w", 7},
+		{"d", 1},
+	} {
+		if i := strings.Index(s, unit.suffix); i >= 0 {
+			n, err := strconv.ParseFloat(s[:i], 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid duration %q", orig)
+			}
+			days += n * unit.days
+			s = s[i+len(unit.suffix):]
+		}
+	}
+	var d time.Duration
+	if s != "" {
+		var err error
+		d, err = time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+	} else if days == 0 {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	// NOTE: float64(math.MaxInt64) is 2^63 which already overflows.
+	daysF := days * float64(24*time.Hour)
+	if !(daysF < math.MaxInt64) || d+time.Duration(daysF) < 0 {
+		return 0, fmt.Errorf("duration %q out of range", orig)
+	}
+	return sign * (d + time.Duration(daysF)), nil
+}
+
+// AddOpDateAdd adds "date_add" operation to the JSONLogic instance. Two forms are accepted:
+//   - [t, duration]: adds a duration string like "1h30m", "-15m" or "30d" (Go duration with extra "d"/"w" units).
+//   - [t, n, unit]: adds n units, unit is one of "year", "month", "week", "day", "hour", "minute", "second"
+//     and "millisecond", "year"/"month" are calendar based and n must be an integer for them.
+//
+// The offset of t is kept in result, examples:
+//   - {"date_add":["2020-01-31T00:00:00Z","36h"]} -> "2020-02-01T12:00:00Z"
+//   - {"date_add":["2020-01-31T00:00:00Z",1,"month"]} -> "2020-03-02T00:00:00Z" (normalized like Go's AddDate)
+func AddOpDateAdd(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("date_add", opDateAdd)
+}
+
+func opDateAdd(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ts, params, err := applyTimes("date_add", 1, 2, 3, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	t := ts[0]
+
+	if len(params) == 2 {
+		s, ok := params[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("date_add: expect duration string but got %T", params[1])
+		}
+		d, err := parseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("date_add: %s", err.Error())
+		}
+		return formatTime(t.Add(d)), nil
+	}
+
+	n, err := jsonlogic.ToNumeric(params[1])
+	if err != nil {
+		return nil, fmt.Errorf("date_add: %s", err.Error())
+	}
+	unit, _ := params[2].(string)
+	switch unit {
+	case "year", "month":
+		if n != math.Trunc(n) {
+			return nil, fmt.Errorf("date_add: expect integer for %s but got %v", unit, n)
+		}
+		if math.Abs(n) > math.MaxInt32 {
+			return nil, fmt.Errorf("date_add: %v %s out of range", n, unit)
+		}
+		if unit == "year" {
+			return formatTime(t.AddDate(int(n), 0, 0)), nil
+		}
+		return formatTime(t.AddDate(0, int(n), 0)), nil
+	}
+	d, ok := durationUnits[unit]
+	if !ok {
+		return nil, fmt.Errorf("date_add: unknown unit %v", params[2])
+	}
+	// NOTE: float64(math.MaxInt64) is 2^63 which already overflows, NaN is also rejected.
+	nd := n * float64(d)
+	if !(math.Abs(nd) < math.MaxInt64) {
+		return nil, fmt.Errorf("date_add: %v %s out of range", n, unit)
+	}
+	return formatTime(t.Add(time.Duration(nd))), nil
+}
+
+// AddOpDateDiff adds "date_diff" operation to the JSONLogic instance, which returns (t1 - t2) in unit
+// (default "second", can also be "week", "day", "hour", "minute" or "millisecond"), may be fractional:
+//   - {"date_diff":["2020-01-02T00:00:00Z","2020-01-01T12:00:00Z","day"]} -> 0.5
+//   - {">":[{"date_diff":[{"now":[]},{"var":"created_at"},"day"]},30]} -> account older than 30 days
+func AddOpDateDiff(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("date_diff", opDateDiff)
+}
+
+func opDateDiff(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ts, params, err := applyTimes("date_diff", 2, 2, 3, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	unit := time.Second
+	if len(params) > 2 {
+		u, _ := params[2].(string)
+		var ok bool
+		unit, ok = durationUnits[u]
+		if !ok {
+			return nil, fmt.Errorf("date_diff: unknown unit %v", params[2])
+		}
+	}
+	return float64(ts[0].Sub(ts[1])) / float64(unit), nil
+}
+
+// AddOpDateBefore adds "date_before" operation to the JSONLogic instance. Like "<", it accepts 2 or 3
+// params, the 3 params form tests whether the second is strictly between the first and the third:
+//   - {"date_before":["2020-01-01T00:00:00Z","2020-01-01T07:00:00+08:00"]} -> false
+//   - {"date_before":["2020-01-01","2020-06-01","2021-01-01"]} -> true
+func AddOpDateBefore(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("date_before", opDateCompare("date_before", func(a, b time.Time) bool { return a.Before(b) }))
+}
+
+// AddOpDateAfter adds "date_after" operation to the JSONLogic instance. Like ">", it accepts 2 or 3 params:
+//   - {"date_after":["2020-01-02","2020-01-01"]} -> true
+func AddOpDateAfter(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("date_after", opDateCompare("date_after", func(a, b time.Time) bool { return a.After(b) }))
+}
+
+func opDateCompare(name string, cmp func(a, b time.Time) bool) jsonlogic.Operation {
+	return func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		n := len(params)
+		if n != 3 {
+			n = 2
+		}
+		ts, _, err := applyTimes(name, n, 2, 3, apply, params, data)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(ts)-1; i++ {
+			if !cmp(ts[i], ts[i+1]) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
+
+// AddOpDatePart adds "date_part" operation to the JSONLogic instance, which extracts a part of date time in
+// the optional time zone (IANA name like "Europe/Berlin", default the offset of the date time itself).
+// Parts are "year", "month" (1-12), "day" (1-31), "hour" (0-23), "minute", "second", "weekday" (0-6,
+// Sunday is 0 like JavaScript's getDay) and "yearday" (1-366):
+//   - {"date_part":["2020-01-02T15:04:05Z","hour","Europe/Berlin"]} -> 16
+//   - {"<=":[9,{"date_part":[{"now":[]},"hour","Europe/Berlin"]},17]} -> within business hours in Berlin
+func AddOpDatePart(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("date_part", opDatePart)
+}
+
+func opDatePart(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ts, params, err := applyTimes("date_part", 1, 2, 3, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	t := ts[0]
+	if len(params) > 2 {
+		tz, ok := params[2].(string)
+		if !ok {
+			return nil, fmt.Errorf("date_part: expect string as time zone but got %T", params[2])
+		}
+		loc, err := loadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("date_part: %s", err.Error())
+		}
+		t = t.In(loc)
+	}
+
+	var r int
+	switch params[1] {
+	case "year":
+		r = t.Year()
+	case "month":
+		r = int(t.Month())
+	case "day":
+		r = t.Day()
+	case "hour":
+		r = t.Hour()
+	case "minute":
+		r = t.Minute()
+	case "second":
+		r = t.Second()
+	case "weekday":
+		r = int(t.Weekday())
+	case "yearday":
+		r = t.YearDay()
+	default:
+		return nil, fmt.Errorf("date_part: unknown part %v", params[1])
+	}
+	return float64(r), nil
+}
+
+// AddOpDateTz adds "date_tz" operation to the JSONLogic instance, which converts a date time to a time zone
+// (IANA name like "Europe/Berlin", or "UTC"/"Local"):
+//   - {"date_tz":["2020-01-02T15:04:05Z","Asia/Shanghai"]} -> "2020-01-02T23:04:05+08:00"
+func AddOpDateTz(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("date_tz", opDateTz)
+}
+
+func opDateTz(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ts, params, err := applyTimes("date_tz", 1, 2, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	tz, ok := params[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("date_tz: expect string as time zone but got %T", params[1])
+	}
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("date_tz: %s", err.Error())
+	}
+	return formatTime(ts[0].In(loc)), nil
+}
@@ -0,0 +1,89 @@
+package ext
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestDatetimeOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.NewEmpty()
+	jsonlogic.AddOpVar(jl)
+	jsonlogic.AddOpGreaterThan(jl)
+	AddDatetimeOps(jl)
+	jl.SetClock(func() time.Time {
+		return time.Date(2020, 1, 2, 23, 4, 5, 0, time.FixedZone("CST", 8*3600))
+	})
+	jsonlogic.TestCases{
+		// datetime.
+		{Logic: `{"datetime":"2020-01-02"}`, Data: `null`, Result: "2020-01-02T00:00:00Z"},
+		{Logic: `{"datetime":"2020-01-02T23:04:05.5+08:00"}`, Data: `null`, Result: "2020-01-02T23:04:05.5+08:00"},
+		{Logic: `{"datetime":0}`, Data: `null`, Result: "1970-01-01T00:00:00Z"},
+		{Logic: `{"datetime":1.5}`, Data: `null`, Result: "1970-01-01T00:00:01.5Z"},
+		{Logic: `{"datetime":"2020-13-01"}`, Data: `null`, Err: true},
+		{Logic: `{"datetime":"yesterday"}`, Data: `null`, Err: true},
+		{Logic: `{"datetime":true}`, Data: `null`, Err: true},
+		{Logic: `{"datetime":-62135596800}`, Data: `null`, Result: "0001-01-01T00:00:00Z"},
+		{Logic: `{"datetime":253402300799.5}`, Data: `null`, Result: "9999-12-31T23:59:59.5Z"},
+		{Logic: `{"datetime":-62135596800.5}`, Data: `null`, Err: true},
+		{Logic: `{"datetime":253402300800}`, Data: `null`, Err: true},
+		{Logic: `{"datetime":1e300}`, Data: `null`, Err: true},
+		{Logic: `{"datetime":-1e300}`, Data: `null`, Err: true},
+		// now.
+		{Logic: `{"now":[]}`, Data: `null`, Result: "2020-01-02T15:04:05Z"},
+		{Logic: `{"now":[1]}`, Data: `null`, Err: true},
+		// date_add.
+		{Logic: `{"date_add":["2020-01-31T00:00:00Z","36h"]}`, Data: `null`, Result: "2020-02-01T12:00:00Z"},
+		{Logic: `{"date_add":["2020-01-31T00:00:00Z","-1d12h"]}`, Data: `null`, Result: "2020-01-29T12:00:00Z"},
+		{Logic: `{"date_add":["2020-01-31T00:00:00Z","2w"]}`, Data: `null`, Result: "2020-02-14T00:00:00Z"},
+		{Logic: `{"date_add":["2020-01-31T00:00:00+08:00",1,"month"]}`, Data: `null`, Result: "2020-03-02T00:00:00+08:00"},
+		{Logic: `{"date_add":["2020-02-29",-1,"year"]}`, Data: `null`, Result: "2019-03-01T00:00:00Z"},
+		{Logic: `{"date_add":["2020-01-01",1.5,"day"]}`, Data: `null`, Result: "2020-01-02T12:00:00Z"},
+		{Logic: `{"date_add":[{"now":[]},-30,"minute"]}`, Data: `null`, Result: "2020-01-02T14:34:05Z"},
+		{Logic: `{"date_add":["2020-01-01",1.5,"month"]}`, Data: `null`, Err: true},
+		{Logic: `{"date_add":["2020-01-01",1,"fortnight"]}`, Data: `null`, Err: true},
+		{Logic: `{"date_add":["2020-01-01","0s"]}`, Data: `null`, Result: "2020-01-01T00:00:00Z"},
+		{Logic: `{"date_add":["2020-01-01",""]}`, Data: `null`, Err: true},
+		{Logic: `{"date_add":["2020-01-01","1d-1h"]}`, Data: `null`, Err: true},
+		{Logic: `{"date_add":["2020-01-01","1x"]}`, Data: `null`, Err: true},
+		{Logic: `{"date_add":["2020-01-01",1]}`, Data: `null`, Err: true},
+		{Logic: `{"date_add":["2020-01-01",1e10,"day"]}`, Data: `null`, Err: true},
+		{Logic: `{"date_add":["2020-01-01",-1e10,"day"]}`, Data: `null`, Err: true},
+		{Logic: `{"date_add":["2020-01-01",106751,"day"]}`, Data: `null`, Result: "2312-04-11T00:00:00Z"},
+		{Logic: `{"date_add":["2020-01-01",1e10,"year"]}`, Data: `null`, Err: true},
+		{Logic: `{"date_add":["2020-01-01","1000000w"]}`, Data: `null`, Err: true},
+		{Logic: `{"date_add":["2020-01-01","106751d24h"]}`, Data: `null`, Err: true},
+		// date_diff.
+		{Logic: `{"date_diff":["2020-01-02T00:00:00Z","2020-01-01T12:00:00Z","day"]}`, Data: `null`, Result: float64(0.5)},
+		{Logic: `{"date_diff":["2020-01-01T00:00:00Z","2020-01-01T00:01:00Z"]}`, Data: `null`, Result: float64(-60)},
+		{Logic: `{">":[{"date_diff":[{"now":[]},{"var":"created_at"},"day"]},30]}`, Data: `{"created_at":"2019-11-01"}`, Result: true},
+		{Logic: `{">":[{"date_diff":[{"now":[]},{"var":"created_at"},"day"]},30]}`, Data: `{"created_at":"2019-12-31"}`, Result: false},
+		{Logic: `{"date_diff":["2020-01-01","2020-01-01","year"]}`, Data: `null`, Err: true},
+		// date_before/date_after.
+		{Logic: `{"date_before":["2020-01-01T00:00:00Z","2020-01-01T07:00:00+08:00"]}`, Data: `null`, Result: false},
+		{Logic: `{"date_before":["2020-01-01","2020-06-01","2021-01-01"]}`, Data: `null`, Result: true},
+		{Logic: `{"date_before":["2020-01-01","2021-06-01","2021-01-01"]}`, Data: `null`, Result: false},
+		{Logic: `{"date_after":["2020-01-02","2020-01-01"]}`, Data: `null`, Result: true},
+		{Logic: `{"date_after":[{"var":"expires_at"},{"now":[]}]}`, Data: `{"expires_at":"2020-01-02T15:04:06Z"}`, Result: true},
+		{Logic: `{"date_after":["2020-01-02"]}`, Data: `null`, Err: true},
+		{Logic: `{"date_after":["2020-01-02",null]}`, Data: `null`, Err: true},
+		// date_part.
+		{Logic: `{"date_part":["2020-01-02T15:04:05Z","hour","Europe/Berlin"]}`, Data: `null`, Result: float64(16)},
+		{Logic: `{"date_part":["2020-07-02T15:04:05Z","hour","Europe/Berlin"]}`, Data: `null`, Result: float64(17)},
+		{Logic: `{"date_part":["2020-01-02T23:04:05+08:00","day"]}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"date_part":["2020-01-02T23:04:05+08:00","day","UTC"]}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"date_part":["2020-01-03T01:04:05+08:00","weekday","UTC"]}`, Data: `null`, Result: float64(4)},
+		{Logic: `{"date_part":["2020-12-31","yearday"]}`, Data: `null`, Result: float64(366)},
+		{Logic: `{"date_part":["2020-12-31","month"]}`, Data: `null`, Result: float64(12)},
+		{Logic: `{"date_part":["2020-12-31","quarter"]}`, Data: `null`, Err: true},
+		{Logic: `{"date_part":["2020-12-31","year","Mars/Olympus"]}`, Data: `null`, Err: true},
+		// date_tz.
+		{Logic: `{"date_tz":["2020-01-02T15:04:05Z","Asia/Shanghai"]}`, Data: `null`, Result: "2020-01-02T23:04:05+08:00"},
+		{Logic: `{"date_tz":["2020-01-02T23:04:05+08:00","UTC"]}`, Data: `null`, Result: "2020-01-02T15:04:05Z"},
+		{Logic: `{"date_tz":["2020-01-02T23:04:05+08:00",8]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
@@ -0,0 +1,304 @@
+package ext
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// AddEqualityOps adds all deep equality and containment operations in this file to the JSONLogic instance:
+// "deep_equal", "contains_all", "contains_any", "is_subset", "intersection", "difference" and "union".
+//
+// Values are compared structurally: numbers by value, strings/booleans/null by identity (no type conversion
+// like "=="), arrays by items in order and objects by keys and values. All of them accept an optional last
+// param "unordered" to compare arrays (including nested ones) as multisets, i.e. [1,2,2] equals [2,1,2]
+// but not [1,1,2]; the default is "ordered".
+//
+// "intersection", "difference" and "union" have set semantics: duplicated items are removed from the results
+// and the order of first appearance is kept.
+func AddEqualityOps(jl *jsonlogic.JSONLogic) {
+	AddOpDeepEqual(jl)
+	AddOpContainsAll(jl)
+	AddOpContainsAny(jl)
+	AddOpIsSubset(jl)
+	AddOpIntersection(jl)
+	AddOpDifference(jl)
+	AddOpUnion(jl)
+}
+
+// canonicalKey returns a string representation of a json value, two values are deeply equal iff they have
+// the same key. If unordered is true, items of arrays are sorted by their keys.
+func canonicalKey(obj interface{}, unordered bool) string {
+	var b strings.Builder
+	writeCanonicalKey(&b, obj, unordered)
+	return b.String()
+}
+
+func writeCanonicalKey(b *strings.Builder, obj interface{}, unordered bool) {
+	switch o := obj.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(o))
+	case float64:
+		if o == 0 {
+			// -0 and 0.
+			o = 0
+		}
+		b.WriteString(strconv.FormatFloat(o, 'g', -1, 64))
+	case string:
+		b.WriteString(strconv.Quote(o))
+	case []interface{}:
+		b.WriteByte('[')
+		if unordered {
+			keys := make([]string, 0, len(o))
+			for _, item := range o {
+				keys = append(keys, canonicalKey(item, unordered))
+			}
+			sort.Strings(keys)
+			b.WriteString(strings.Join(keys, ","))
+		} else {
+			for i, item := range o {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				writeCanonicalKey(b, item, unordered)
+			}
+		}
+		b.WriteByte(']')
+	case map[string]interface{}:
+		b.WriteByte('{')
+		for i, key := range sortedKeys(o) {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(strconv.Quote(key))
+			b.WriteByte(':')
+			writeCanonicalKey(b, o[key], unordered)
+		}
+		b.WriteByte('}')
+	default:
+		panic(fmt.Errorf("canonicalKey got non-json type %T", obj))
+	}
+}
+
+// applyCompareMode evaluates params and strips the optional trailing "ordered"/"unordered" mode, which is
+// only recognized when there are more than n params.
+func applyCompareMode(name string, n int, apply jsonlogic.Applier, params []interface{}, data interface{}) ([]interface{}, bool, error) {
+	params, err := applyN(name, apply, params, data, n, n+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(params) == n {
+		return params, false, nil
+	}
+	switch params[n] {
+	case "ordered":
+		return params[:n], false, nil
+	case "unordered":
+		return params[:n], true, nil
+	default:
+		return nil, false, fmt.Errorf("%s: expect \"ordered\" or \"unordered\" but got %v", name, params[n])
+	}
+}
+
+// keySet returns the set of canonical keys of items.
+func keySet(items []interface{}, unordered bool) map[string]struct{} {
+	ret := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		ret[canonicalKey(item, unordered)] = struct{}{}
+	}
+	return ret
+}
+
+// AddOpDeepEqual adds "deep_equal" operation to the JSONLogic instance:
+//   - {"deep_equal":[{"var":"tags"},["a","b"]]} -> true if tags is ["a","b"]
+//   - {"deep_equal":[["a","b"],["b","a"],"unordered"]} -> true
+//   - {"deep_equal":[1,"1"]} -> false
+func AddOpDeepEqual(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("deep_equal", opDeepEqual)
+}
+
+func opDeepEqual(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, unordered, err := applyCompareMode("deep_equal", 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalKey(params[0], unordered) == canonicalKey(params[1], unordered), nil
+}
+
+// itemsParam converts the items param of "contains_all"/"contains_any": an array of items, or a single
+// non-array item.
+func itemsParam(obj interface{}) []interface{} {
+	if arr, ok := obj.([]interface{}); ok {
+		return arr
+	}
+	return []interface{}{obj}
+}
+
+// AddOpContainsAll adds "contains_all" operation to the JSONLogic instance, which tests whether an array
+// contains all the items (an array, or a single non-array item). It is true if there is no item:
+//   - {"contains_all":[{"var":"permissions"},{"object":["role","admin"]}]} -> true if permissions has {"role":"admin"}
+//   - {"contains_all":[["a","b","c"],["c","a"]]} -> true
+func AddOpContainsAll(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("contains_all", opContains("contains_all", true))
+}
+
+// AddOpContainsAny adds "contains_any" operation to the JSONLogic instance, which tests whether an array
+// contains any of the items (an array, or a single non-array item). It is false if there is no item:
+//   - {"contains_any":[{"var":"tags"},["vip","beta"]]}
+func AddOpContainsAny(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("contains_any", opContains("contains_any", false))
+}
+
+func opContains(name string, all bool) jsonlogic.Operation {
+	return func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		params, unordered, err := applyCompareMode(name, 2, apply, params, data)
+		if err != nil {
+			return nil, err
+		}
+		arr, err := toArray(name, params[0])
+		if err != nil {
+			return nil, err
+		}
+		set := keySet(arr, unordered)
+		for _, item := range itemsParam(params[1]) {
+			_, ok := set[canonicalKey(item, unordered)]
+			if ok != all {
+				return ok, nil
+			}
+		}
+		return all, nil
+	}
+}
+
+// AddOpIsSubset adds "is_subset" operation to the JSONLogic instance, which tests whether the first param
+// is a subset of the second:
+//   - Arrays: every item of the first is deeply equal to some item of the second (duplicates ignored).
+//   - Objects: every key of the first exists in the second with a deeply equal value.
+//
+// Examples:
+//   - {"is_subset":[["a","a"],["a","b"]]} -> true
+//   - {"is_subset":[{"object":["plan","pro"]},{"var":"account"}]} -> true if account.plan is "pro"
+func AddOpIsSubset(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("is_subset", opIsSubset)
+}
+
+func opIsSubset(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, unordered, err := applyCompareMode("is_subset", 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	switch a := params[0].(type) {
+	case []interface{}:
+		b, ok := params[1].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("is_subset: expect two arrays or two objects but got %T and %T", params[0], params[1])
+		}
+		set := keySet(b, unordered)
+		for _, item := range a {
+			if _, ok := set[canonicalKey(item, unordered)]; !ok {
+				return false, nil
+			}
+		}
+		return true, nil
+	case map[string]interface{}:
+		b, ok := params[1].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("is_subset: expect two arrays or two objects but got %T and %T", params[0], params[1])
+		}
+		for k, v := range a {
+			bv, ok := b[k]
+			if !ok || canonicalKey(v, unordered) != canonicalKey(bv, unordered) {
+				return false, nil
+			}
+		}
+		return true, nil
+	default:
+		return nil, fmt.Errorf("is_subset: expect two arrays or two objects but got %T and %T", params[0], params[1])
+	}
+}
+
+// applySetOperands evaluates params of "intersection"/"difference"/"union" to two arrays.
+func applySetOperands(name string, apply jsonlogic.Applier, params []interface{}, data interface{}) ([]interface{}, []interface{}, bool, error) {
+	params, unordered, err := applyCompareMode(name, 2, apply, params, data)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	a, err := toArray(name, params[0])
+	if err != nil {
+		return nil, nil, false, err
+	}
+	b, err := toArray(name, params[1])
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return a, b, unordered, nil
+}
+
+// setFilter returns distinct items of arr whose presence in set equals want.
+func setFilter(arr []interface{}, set map[string]struct{}, want bool, unordered bool) []interface{} {
+	seen := make(map[string]struct{}, len(arr))
+	ret := []interface{}{}
+	for _, item := range arr {
+		k := canonicalKey(item, unordered)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		if _, ok := set[k]; ok == want {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+// AddOpIntersection adds "intersection" operation to the JSONLogic instance, which returns distinct items
+// of the first array that are also in the second:
+//   - {"intersection":[["a","b","a"],["b","a","c"]]} -> ["a","b"]
+func AddOpIntersection(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("intersection", opIntersection)
+}
+
+func opIntersection(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	a, b, unordered, err := applySetOperands("intersection", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return setFilter(a, keySet(b, unordered), true, unordered), nil
+}
+
+// AddOpDifference adds "difference" operation to the JSONLogic instance, which returns distinct items
+// of the first array that are not in the second:
+//   - {"difference":[["a","b","c"],["b"]]} -> ["a","c"]
+func AddOpDifference(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("difference", opDifference)
+}
+
+func opDifference(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	a, b, unordered, err := applySetOperands("difference", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return setFilter(a, keySet(b, unordered), false, unordered), nil
+}
+
+// AddOpUnion adds "union" operation to the JSONLogic instance, which returns distinct items of both arrays:
+//   - {"union":[["a","b"],["b","c"]]} -> ["a","b","c"]
+func AddOpUnion(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("union", opUnion)
+}
+
+func opUnion(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	a, b, unordered, err := applySetOperands("union", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	all := make([]interface{}, 0, len(a)+len(b))
+	all = append(all, a...)
+	all = append(all, b...)
+	return setFilter(all, map[string]struct{}{}, false, unordered), nil
+}
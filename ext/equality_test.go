@@ -0,0 +1,64 @@
+package ext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestEqualityOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+	AddObjectOps(jl)
+	AddEqualityOps(jl)
+	jsonlogic.TestCases{
+		// deep_equal.
+		{Logic: `{"deep_equal":[{"var":"tags"},["a","b"]]}`, Data: `{"tags":["a","b"]}`, Result: true},
+		{Logic: `{"deep_equal":[{"var":"tags"},["b","a"]]}`, Data: `{"tags":["a","b"]}`, Result: false},
+		{Logic: `{"deep_equal":[["a","b"],["b","a"],"unordered"]}`, Data: `null`, Result: true},
+		{Logic: `{"deep_equal":[[1,2,2],[2,1,1],"unordered"]}`, Data: `null`, Result: false},
+		{Logic: `{"deep_equal":[[[1,2],3],[3,[2,1]],"unordered"]}`, Data: `null`, Result: true},
+		{Logic: `{"deep_equal":[[[1,2],3],[3,[2,1]],"ordered"]}`, Data: `null`, Result: false},
+		{Logic: `{"deep_equal":[{"var":"a"},{"var":"b"}]}`, Data: `{"a":{"x":[1,{"y":null}],"z":true},"b":{"z":true,"x":[1,{"y":null}]}}`, Result: true},
+		{Logic: `{"deep_equal":[{"var":"a"},{"var":"b"}]}`, Data: `{"a":{"x":1},"b":{"x":1,"y":null}}`, Result: false},
+		{Logic: `{"deep_equal":[1,"1"]}`, Data: `null`, Result: false},
+		{Logic: `{"deep_equal":[0,{"-":0}]}`, Data: `null`, Result: true},
+		{Logic: `{"deep_equal":[null,null]}`, Data: `null`, Result: true},
+		{Logic: `{"deep_equal":[1,1,"sorted"]}`, Data: `null`, Err: true},
+		{Logic: `{"deep_equal":[1]}`, Data: `null`, Err: true},
+		// contains_all/contains_any.
+		{Logic: `{"contains_all":[{"var":"permissions"},{"object":["role","admin"]}]}`, Data: `{"permissions":[{"role":"user"},{"role":"admin"}]}`, Result: true},
+		{Logic: `{"contains_all":[{"var":"permissions"},{"object":["role","root"]}]}`, Data: `{"permissions":[{"role":"user"},{"role":"admin"}]}`, Result: false},
+		{Logic: `{"contains_all":[["a","b","c"],["c","a"]]}`, Data: `null`, Result: true},
+		{Logic: `{"contains_all":[["a","b","c"],["c","d"]]}`, Data: `null`, Result: false},
+		{Logic: `{"contains_all":[["a"],[]]}`, Data: `null`, Result: true},
+		{Logic: `{"contains_all":[[[1,2]],[[2,1]],"unordered"]}`, Data: `null`, Result: true},
+		{Logic: `{"contains_all":[[[1,2]],[[2,1]]]}`, Data: `null`, Result: false},
+		{Logic: `{"contains_any":[{"var":"tags"},["vip","beta"]]}`, Data: `{"tags":["beta"]}`, Result: true},
+		{Logic: `{"contains_any":[{"var":"tags"},["vip","beta"]]}`, Data: `{}`, Result: false},
+		{Logic: `{"contains_any":[["a"],[]]}`, Data: `null`, Result: false},
+		{Logic: `{"contains_any":["abc","a"]}`, Data: `null`, Err: true},
+		// is_subset.
+		{Logic: `{"is_subset":[["a","a"],["a","b"]]}`, Data: `null`, Result: true},
+		{Logic: `{"is_subset":[["a","c"],["a","b"]]}`, Data: `null`, Result: false},
+		{Logic: `{"is_subset":[{"object":["plan","pro"]},{"var":"account"}]}`, Data: `{"account":{"plan":"pro","seats":3}}`, Result: true},
+		{Logic: `{"is_subset":[{"object":["plan","pro"]},{"var":"account"}]}`, Data: `{"account":{"plan":"free"}}`, Result: false},
+		{Logic: `{"is_subset":[{"object":["x",null]},{"var":"account"}]}`, Data: `{"account":{}}`, Result: false},
+		{Logic: `{"is_subset":[{"object":["s",[2,1]]},{"var":""},"unordered"]}`, Data: `{"s":[1,2]}`, Result: true},
+		{Logic: `{"is_subset":[["a"],{"var":""}]}`, Data: `{"a":1}`, Err: true},
+		{Logic: `{"is_subset":["a","ab"]}`, Data: `null`, Err: true},
+		// intersection/difference/union.
+		{Logic: `{"intersection":[["a","b","a"],["b","a","c"]]}`, Data: `null`, Result: []interface{}{"a", "b"}},
+		{Logic: `{"intersection":[[[1,2],[3]],[[2,1]],"unordered"]}`, Data: `null`, Result: []interface{}{[]interface{}{float64(1), float64(2)}}},
+		{Logic: `{"intersection":[[1],null]}`, Data: `null`, Result: []interface{}{}},
+		{Logic: `{"difference":[["a","b","c","a"],["b"]]}`, Data: `null`, Result: []interface{}{"a", "c"}},
+		{Logic: `{"difference":[[1,"1"],[1]]}`, Data: `null`, Result: []interface{}{"1"}},
+		{Logic: `{"union":[["a","b"],["b","c"]]}`, Data: `null`, Result: []interface{}{"a", "b", "c"}},
+		{Logic: `{"union":[[{"x":[1,2],"y":1}],[{"y":1,"x":[2,1]}],"unordered"]}`, Data: `null`, Result: []interface{}{
+			map[string]interface{}{"x": []interface{}{float64(1), float64(2)}, "y": float64(1)},
+		}},
+		{Logic: `{"union":[["a"],"b"]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
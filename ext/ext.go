@@ -3,12 +3,16 @@ package ext
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/huangjunwen/jsonlogic-go"
 )
 
+// maxRangeLength is the max number of items generated by "range" to avoid huge allocation.
+const maxRangeLength = 1 << 20
+
 // AddOpRange adds "range" operation to the JSONLogic instance. The op accept 1 to 3 numeric params and
-// generate a range of numbers, examples:
+// generate a range of at most 1<<20 numbers, examples:
 //   - {"range":null} -> []
 //   - {"range":0} -> []
 //   - {"range":2} -> [0,1]
@@ -74,7 +78,10 @@ func opRange(apply jsonlogic.Applier, params []interface{}, data interface{}) (r
 		if step < 0 {
 			return nil, fmt.Errorf("range: end > begin but got negative step")
 		}
-		ret := []interface{}{}
+		ret, err := makeRange(begin, end, step)
+		if err != nil {
+			return nil, err
+		}
 		for i := begin; i < end; i += step {
 			ret = append(ret, float64(i))
 		}
@@ -87,9 +94,43 @@ func opRange(apply jsonlogic.Applier, params []interface{}, data interface{}) (r
 	if step > 0 {
 		return nil, fmt.Errorf("range: end < begin but got postive step")
 	}
-	ret := []interface{}{}
+	ret, err := makeRange(begin, end, step)
+	if err != nil {
+		return nil, err
+	}
 	for i := begin; i > end; i += step {
 		ret = append(ret, float64(i))
 	}
 	return ret, nil
 }
+
+// makeRange returns an empty slice with enough capacity for the range, or an error if the range is too long.
+func makeRange(begin, end, step int) ([]interface{}, error) {
+	n := math.Ceil((float64(end) - float64(begin)) / float64(step))
+	if n > maxRangeLength {
+		return nil, fmt.Errorf("range: too many items (%.0f)", n)
+	}
+	return make([]interface{}, 0, int(n)), nil
+}
+
+// checkParams checks the number of params is between min and max, negative max means no limit.
+func checkParams(name string, params []interface{}, min, max int) error {
+	switch {
+	case len(params) >= min && (max < 0 || len(params) <= max):
+		return nil
+	case min == max:
+		return fmt.Errorf("%s: expect %d params", name, min)
+	case max < 0:
+		return fmt.Errorf("%s: expect at least %d params", name, min)
+	default:
+		return fmt.Errorf("%s: expect %d to %d params", name, min, max)
+	}
+}
+
+// applyN checks the number of params (see checkParams) and evaluates them.
+func applyN(name string, apply jsonlogic.Applier, params []interface{}, data interface{}, min, max int) ([]interface{}, error) {
+	if err := checkParams(name, params, min, max); err != nil {
+		return nil, err
+	}
+	return jsonlogic.ApplyParams(apply, params, data)
+}
@@ -23,5 +23,8 @@ func TestOpRange(t *testing.T) {
 		{Logic: `{"range":[6,3,-2]}`, Data: `null`, Result: []interface{}{float64(6), float64(4)}},
 		{Logic: `{"range":[3,6,-1]}`, Data: `null`, Err: true},
 		{Logic: `{"range":[6,3,2]}`, Data: `null`, Err: true},
+		{Logic: `{"range":1e12}`, Data: `null`, Err: true},
+		{Logic: `{"range":-1e12}`, Data: `null`, Err: true},
+		{Logic: `{"range":[0,3e9,1e9]}`, Data: `null`, Result: []interface{}{float64(0), float64(1e9), float64(2e9)}},
 	}.Run(assert, jl)
 }
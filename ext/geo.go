@@ -0,0 +1,216 @@
+package ext
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// EarthRadius is the mean radius of the earth in metres used by geo operations.
+const EarthRadius = 6371008.8
+
+// AddGeoOps adds all geospatial operations in this file to the JSONLogic instance:
+// "geo_distance", "geo_within_radius", "geo_in_polygon" and "geo_in_bbox".
+//
+// A point is a [lat, lng] array or a {"lat":lat, "lng":lng} object in degrees (WGS84). Coordinates must be
+// numbers (no conversion from strings) with latitude in [-90, 90] and longitude in [-180, 180], otherwise
+// it is an error. Distances are great-circle distances in metres by the haversine formula with EarthRadius,
+// which may differ from geodesic distances by up to about 0.5%.
+func AddGeoOps(jl *jsonlogic.JSONLogic) {
+	AddOpGeoDistance(jl)
+	AddOpGeoWithinRadius(jl)
+	AddOpGeoInPolygon(jl)
+	AddOpGeoInBBox(jl)
+}
+
+// point is a geo point in degrees.
+type point struct {
+	lat float64
+	lng float64
+}
+
+// toPoint converts a [lat, lng] array or {"lat":lat, "lng":lng} object to point.
+func toPoint(name string, obj interface{}) (point, error) {
+	var lat, lng interface{}
+	switch o := obj.(type) {
+	case []interface{}:
+		if len(o) != 2 {
+			return point{}, fmt.Errorf("%s: expect [lat, lng] as point but got %d items", name, len(o))
+		}
+		lat, lng = o[0], o[1]
+	case map[string]interface{}:
+		var ok1, ok2 bool
+		lat, ok1 = o["lat"]
+		lng, ok2 = o["lng"]
+		if !ok1 || !ok2 {
+			return point{}, fmt.Errorf("%s: expect {\"lat\":lat, \"lng\":lng} as point", name)
+		}
+	default:
+		return point{}, fmt.Errorf("%s: expect [lat, lng] or {\"lat\":lat, \"lng\":lng} as point but got %T", name, obj)
+	}
+
+	var p point
+	var ok bool
+	if p.lat, ok = lat.(float64); !ok {
+		return point{}, fmt.Errorf("%s: expect number as latitude but got %T", name, lat)
+	}
+	if p.lng, ok = lng.(float64); !ok {
+		return point{}, fmt.Errorf("%s: expect number as longitude but got %T", name, lng)
+	}
+	if !(p.lat >= -90 && p.lat <= 90) {
+		return point{}, fmt.Errorf("%s: latitude %v out of range [-90, 90]", name, p.lat)
+	}
+	if !(p.lng >= -180 && p.lng <= 180) {
+		return point{}, fmt.Errorf("%s: longitude %v out of range [-180, 180]", name, p.lng)
+	}
+	return p, nil
+}
+
+// applyPoints checks the number of params, evaluates them and converts the first n of them to points.
+func applyPoints(name string, n, total int, apply jsonlogic.Applier, params []interface{}, data interface{}) ([]point, []interface{}, error) {
+	params, err := applyN(name, apply, params, data, total, total)
+	if err != nil {
+		return nil, nil, err
+	}
+	points := make([]point, 0, n)
+	for _, param := range params[:n] {
+		p, err := toPoint(name, param)
+		if err != nil {
+			return nil, nil, err
+		}
+		points = append(points, p)
+	}
+	return points, params[n:], nil
+}
+
+// haversine returns the great-circle distance in metres between two points.
+func haversine(a, b point) float64 {
+	const rad = math.Pi / 180
+	dLat := (b.lat - a.lat) * rad
+	dLng := (b.lng - a.lng) * rad
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(a.lat*rad)*math.Cos(b.lat*rad)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+// AddOpGeoDistance adds "geo_distance" operation to the JSONLogic instance, which returns the distance
+// between two points in metres:
+//   - {"geo_distance":[{"var":"user.location"},[51.5007,-0.1246]]}
+func AddOpGeoDistance(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("geo_distance", opGeoDistance)
+}
+
+func opGeoDistance(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	points, _, err := applyPoints("geo_distance", 2, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return haversine(points[0], points[1]), nil
+}
+
+// AddOpGeoWithinRadius adds "geo_within_radius" operation to the JSONLogic instance, which tests whether
+// the distance between a point and a center is not greater than a radius in metres (converted by
+// jsonlogic.ToNumeric, must not be negative):
+//   - {"geo_within_radius":[{"var":"address"},{"var":"store"},5000]}
+func AddOpGeoWithinRadius(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("geo_within_radius", opGeoWithinRadius)
+}
+
+func opGeoWithinRadius(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	points, rest, err := applyPoints("geo_within_radius", 2, 3, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	radius, err := jsonlogic.ToNumeric(rest[0])
+	if err != nil {
+		return nil, fmt.Errorf("geo_within_radius: %s", err.Error())
+	}
+	if !(radius >= 0) || math.IsInf(radius, 0) {
+		return nil, fmt.Errorf("geo_within_radius: expect non-negative radius but got %v", radius)
+	}
+	return haversine(points[0], points[1]) <= radius, nil
+}
+
+// AddOpGeoInPolygon adds "geo_in_polygon" operation to the JSONLogic instance, which tests whether a point
+// is inside a polygon, an array of at least 3 vertices (closing the ring by repeating the first vertex is
+// optional). Points on the boundary are inside:
+//   - {"geo_in_polygon":[{"var":"address"},[[0,0],[0,10],[10,10],[10,0]]]}
+//
+// NOTE: Edges are straight lines in the lat/lng plane, not great circles, which is fine for zones of city
+// scale. Polygons crossing the antimeridian (180th meridian) or containing a pole are not supported.
+func AddOpGeoInPolygon(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("geo_in_polygon", opGeoInPolygon)
+}
+
+func opGeoInPolygon(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	points, rest, err := applyPoints("geo_in_polygon", 1, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := rest[0].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("geo_in_polygon: expect array of points as polygon but got %T", rest[0])
+	}
+	polygon := make([]point, 0, len(arr))
+	for _, item := range arr {
+		p, err := toPoint("geo_in_polygon", item)
+		if err != nil {
+			return nil, err
+		}
+		polygon = append(polygon, p)
+	}
+	if len(polygon) > 1 && polygon[0] == polygon[len(polygon)-1] {
+		polygon = polygon[:len(polygon)-1]
+	}
+	if len(polygon) < 3 {
+		return nil, fmt.Errorf("geo_in_polygon: expect at least 3 vertices in polygon but got %d", len(polygon))
+	}
+	return inPolygon(points[0], polygon), nil
+}
+
+// inPolygon tests whether p is inside or on the boundary of polygon by ray casting, with lng as x and lat as y.
+func inPolygon(p point, polygon []point) bool {
+	in := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		// On the edge.
+		cross := (b.lng-a.lng)*(p.lat-a.lat) - (b.lat-a.lat)*(p.lng-a.lng)
+		if cross == 0 &&
+			p.lng >= math.Min(a.lng, b.lng) && p.lng <= math.Max(a.lng, b.lng) &&
+			p.lat >= math.Min(a.lat, b.lat) && p.lat <= math.Max(a.lat, b.lat) {
+			return true
+		}
+		if (a.lat > p.lat) != (b.lat > p.lat) &&
+			p.lng < (b.lng-a.lng)*(p.lat-a.lat)/(b.lat-a.lat)+a.lng {
+			in = !in
+		}
+	}
+	return in
+}
+
+// AddOpGeoInBBox adds "geo_in_bbox" operation to the JSONLogic instance, which tests whether a point is
+// inside (or on the boundary of) a bounding box given by its south-west and north-east corners. If the west
+// longitude is greater than the east one, the box crosses the antimeridian:
+//   - {"geo_in_bbox":[{"var":"address"},[51.28,-0.51],[51.69,0.33]]}
+//   - {"geo_in_bbox":[[0,179.5],[-10,170],[10,-170]]} -> true
+func AddOpGeoInBBox(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("geo_in_bbox", opGeoInBBox)
+}
+
+func opGeoInBBox(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	points, _, err := applyPoints("geo_in_bbox", 3, 3, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	p, sw, ne := points[0], points[1], points[2]
+	if sw.lat > ne.lat {
+		return nil, fmt.Errorf("geo_in_bbox: south latitude %v is greater than north latitude %v", sw.lat, ne.lat)
+	}
+	if p.lat < sw.lat || p.lat > ne.lat {
+		return false, nil
+	}
+	if sw.lng <= ne.lng {
+		return p.lng >= sw.lng && p.lng <= ne.lng, nil
+	}
+	return p.lng >= sw.lng || p.lng <= ne.lng, nil
+}
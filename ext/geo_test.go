@@ -0,0 +1,60 @@
+package ext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestGeoOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+	AddGeoOps(jl)
+	AddOpRound(jl)
+	jsonlogic.TestCases{
+		// geo_distance.
+		{Logic: `{"round":{"geo_distance":[[51.5007,-0.1246],[48.8584,2.2945]]}}`, Data: `null`, Result: float64(340539)},
+		{Logic: `{"round":{"geo_distance":[{"var":"a"},{"var":"b"}]}}`, Data: `{"a":{"lat":0,"lng":0},"b":[0,1]}`, Result: float64(111195)},
+		{Logic: `{"round":{"geo_distance":[[0,179.5],[0,-179.5]]}}`, Data: `null`, Result: float64(111195)},
+		{Logic: `{"round":{"geo_distance":[[90,0],[-90,0]]}}`, Data: `null`, Result: float64(20015114)},
+		{Logic: `{"geo_distance":[[1,2],[1,2]]}`, Data: `null`, Result: float64(0)},
+		{Logic: `{"geo_distance":[[91,0],[0,0]]}`, Data: `null`, Err: true},
+		{Logic: `{"geo_distance":[[0,-181],[0,0]]}`, Data: `null`, Err: true},
+		{Logic: `{"geo_distance":[["1","2"],[0,0]]}`, Data: `null`, Err: true},
+		{Logic: `{"geo_distance":[[1,2,3],[0,0]]}`, Data: `null`, Err: true},
+		{Logic: `{"geo_distance":[{"var":"a"},[0,0]]}`, Data: `{"a":{"lat":0,"lon":0}}`, Err: true},
+		{Logic: `{"geo_distance":[null,[0,0]]}`, Data: `null`, Err: true},
+		{Logic: `{"geo_distance":[[0,0]]}`, Data: `null`, Err: true},
+		// geo_within_radius.
+		{Logic: `{"geo_within_radius":[[0,0.04],[0,0],5000]}`, Data: `null`, Result: true},
+		{Logic: `{"geo_within_radius":[[0,0.05],[0,0],5000]}`, Data: `null`, Result: false},
+		{Logic: `{"geo_within_radius":[[0,0.04],[0,0],"5000"]}`, Data: `null`, Result: true},
+		{Logic: `{"geo_within_radius":[[0,0],[0,0],0]}`, Data: `null`, Result: true},
+		{Logic: `{"geo_within_radius":[[0,0],[0,0],-1]}`, Data: `null`, Err: true},
+		{Logic: `{"geo_within_radius":[[0,0],[0,0],"abc"]}`, Data: `null`, Err: true},
+		{Logic: `{"geo_within_radius":[[0,0],[0,0]]}`, Data: `null`, Err: true},
+		// geo_in_polygon.
+		{Logic: `{"geo_in_polygon":[[5,5],[[0,0],[0,10],[10,10],[10,0]]]}`, Data: `null`, Result: true},
+		{Logic: `{"geo_in_polygon":[[5,11],[[0,0],[0,10],[10,10],[10,0]]]}`, Data: `null`, Result: false},
+		{Logic: `{"geo_in_polygon":[[0,5],[[0,0],[0,10],[10,10],[10,0],[0,0]]]}`, Data: `null`, Result: true},
+		{Logic: `{"geo_in_polygon":[[10,10],[[0,0],[0,10],[10,10],[10,0]]]}`, Data: `null`, Result: true},
+		// Concave (U shape).
+		{Logic: `{"geo_in_polygon":[[8,5],[[0,0],[10,0],[10,4],[2,4],[2,6],[10,6],[10,10],[0,10]]]}`, Data: `null`, Result: false},
+		{Logic: `{"geo_in_polygon":[[1,5],[[0,0],[10,0],[10,4],[2,4],[2,6],[10,6],[10,10],[0,10]]]}`, Data: `null`, Result: true},
+		{Logic: `{"geo_in_polygon":[{"var":"p"},{"var":"zone"}]}`, Data: `{"p":{"lat":1,"lng":1},"zone":[{"lat":0,"lng":0},{"lat":0,"lng":2},{"lat":2,"lng":0}]}`, Result: true},
+		{Logic: `{"geo_in_polygon":[[1,1],[[0,0],[0,2],[0,0]]]}`, Data: `null`, Err: true},
+		{Logic: `{"geo_in_polygon":[[1,1],[[0,0],[0,2],[2,200]]]}`, Data: `null`, Err: true},
+		{Logic: `{"geo_in_polygon":[[1,1],"zone"]}`, Data: `null`, Err: true},
+		// geo_in_bbox.
+		{Logic: `{"geo_in_bbox":[[51.5,-0.12],[51.28,-0.51],[51.69,0.33]]}`, Data: `null`, Result: true},
+		{Logic: `{"geo_in_bbox":[[48.85,2.35],[51.28,-0.51],[51.69,0.33]]}`, Data: `null`, Result: false},
+		{Logic: `{"geo_in_bbox":[[51.28,0.33],[51.28,-0.51],[51.69,0.33]]}`, Data: `null`, Result: true},
+		{Logic: `{"geo_in_bbox":[[0,179.5],[-10,170],[10,-170]]}`, Data: `null`, Result: true},
+		{Logic: `{"geo_in_bbox":[[0,-175],[-10,170],[10,-170]]}`, Data: `null`, Result: true},
+		{Logic: `{"geo_in_bbox":[[0,0],[-10,170],[10,-170]]}`, Data: `null`, Result: false},
+		{Logic: `{"geo_in_bbox":[[0,0],[10,-1],[-10,1]]}`, Data: `null`, Err: true},
+		{Logic: `{"geo_in_bbox":[[0,0],[-10,-1]]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
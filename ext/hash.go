@@ -0,0 +1,222 @@
+package ext
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+const (
+	// defaultBucketPrecision is the default number of decimal digits of "bucket" results.
+	defaultBucketPrecision = 2
+
+	// maxBucketPrecision is the max number of decimal digits of "bucket" results, so that all intermediate
+	// values are exact in float64 (and JavaScript numbers).
+	maxBucketPrecision = 4
+)
+
+// AddHashOps adds all hashing operations in this file to the JSONLogic instance: "bucket", "sha256",
+// "murmur3" and "fnv".
+//
+// Results are deterministic across platforms and versions, so they are suitable for persistent assignments
+// like gradual rollouts and A/B tests. Inputs must be strings or numbers (numbers are formatted by
+// jsonlogic.ToString, e.g. 42 -> "42", use strings for ids which do not fit in float64), null/missing values
+// are errors so that they are not silently put into one bucket. Strings are hashed as their UTF-8 bytes.
+//
+// To reproduce the results in JavaScript (TextEncoder gives UTF-8 bytes):
+//   - sha256: hex digest of the UTF-8 bytes, e.g. crypto.subtle.digest("SHA-256", ...).
+//   - murmur3: MurmurHash3_x86_32 of the UTF-8 bytes as an unsigned 32-bit integer, e.g. murmurhash-js's
+//     murmurhash3_32_gc applied to unescape(encodeURIComponent(s)).
+//   - fnv: FNV-1a 32-bit of the UTF-8 bytes as an unsigned 32-bit integer:
+//     let h = 0x811c9dc5; for (const b of bytes) { h = Math.imul(h ^ b, 0x01000193) >>> 0 }
+//   - bucket: Math.floor(murmur3(seed + ":" + key, 0) * 100 * 10**precision / 2**32) / 10**precision
+func AddHashOps(jl *jsonlogic.JSONLogic) {
+	AddOpBucket(jl)
+	AddOpSha256(jl)
+	AddOpMurmur3(jl)
+	AddOpFnv(jl)
+}
+
+// hashInput converts a hash input (string or number) to string.
+func hashInput(name string, obj interface{}) (string, error) {
+	switch obj.(type) {
+	case string, float64:
+		s, err := jsonlogic.ToString(obj)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", name, err.Error())
+		}
+		return s, nil
+	default:
+		return "", fmt.Errorf("%s: expect string or number as hash input but got %T", name, obj)
+	}
+}
+
+// toUint32 converts an integer in [0, 2^32) to uint32.
+func toUint32(name, what string, obj interface{}) (uint32, error) {
+	n, ok := obj.(float64)
+	if !ok || n != math.Trunc(n) || n < 0 || n > math.MaxUint32 {
+		return 0, fmt.Errorf("%s: expect integer in [0, 2^32) as %s but got %v", name, what, obj)
+	}
+	return uint32(n), nil
+}
+
+// Murmur3 returns the 32-bit MurmurHash3 (x86_32 variant) of data with seed.
+func Murmur3(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+	h := seed
+	n := len(data) / 4 * 4
+	for i := 0; i < n; i += 4 {
+		k := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	var k uint32
+	switch len(data) - n {
+	case 3:
+		k ^= uint32(data[n+2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(data[n+1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(data[n])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// Bucket maps seed and key to a number in [0, 100) with precision decimal digits, see AddHashOps for
+// the algorithm.
+func Bucket(seed, key string, precision int) float64 {
+	scale := math.Pow10(precision)
+	h := float64(Murmur3([]byte(seed+":"+key), 0))
+	return math.Floor(h*100*scale/(1<<32)) / scale
+}
+
+// AddOpBucket adds "bucket" operation to the JSONLogic instance. Param restriction:
+//   - Two to three params: seed, key and an optional precision (integer in [0, 4], default 2).
+//
+// It maps the key into [0, 100) uniformly and deterministically, the same key always gets the same bucket
+// for the same seed. Use a different seed per experiment so that buckets of different experiments are
+// independent. For example, to roll out a feature to 12.5% of users:
+//   - {"<":[{"bucket":["new-checkout",{"var":"user_id"}]},12.5]}
+//   - {"bucket":["new-checkout","user-42",0]} -> an integer in [0, 99]
+func AddOpBucket(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("bucket", opBucket)
+}
+
+func opBucket(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, err := applyN("bucket", apply, params, data, 2, 3)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := hashInput("bucket", params[0])
+	if err != nil {
+		return nil, err
+	}
+	key, err := hashInput("bucket", params[1])
+	if err != nil {
+		return nil, err
+	}
+	precision := defaultBucketPrecision
+	if len(params) > 2 {
+		p, ok := params[2].(float64)
+		if !ok || p != math.Trunc(p) || p < 0 || p > maxBucketPrecision {
+			return nil, fmt.Errorf("bucket: expect integer in [0, %d] as precision but got %v", maxBucketPrecision, params[2])
+		}
+		precision = int(p)
+	}
+	return Bucket(seed, key, precision), nil
+}
+
+// AddOpSha256 adds "sha256" operation to the JSONLogic instance, which returns the lower case hex SHA-256
+// digest:
+//   - {"sha256":"abc"} -> "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+func AddOpSha256(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("sha256", opSha256)
+}
+
+func opSha256(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	r, err := applyOne("sha256", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	s, err := hashInput("sha256", r)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AddOpMurmur3 adds "murmur3" operation to the JSONLogic instance, which returns the 32-bit MurmurHash3
+// (x86_32 variant) as an unsigned integer. The optional second param is the seed (integer in [0, 2^32),
+// default 0):
+//   - {"murmur3":"hello"} -> 613153351
+//   - {"murmur3":["hello",1]}
+func AddOpMurmur3(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("murmur3", opMurmur3)
+}
+
+func opMurmur3(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, err := applyN("murmur3", apply, params, data, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+	s, err := hashInput("murmur3", params[0])
+	if err != nil {
+		return nil, err
+	}
+	var seed uint32
+	if len(params) > 1 {
+		seed, err = toUint32("murmur3", "seed", params[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return float64(Murmur3([]byte(s), seed)), nil
+}
+
+// AddOpFnv adds "fnv" operation to the JSONLogic instance, which returns the 32-bit FNV-1a hash as an
+// unsigned integer:
+//   - {"fnv":"a"} -> 3826002220
+func AddOpFnv(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("fnv", opFnv)
+}
+
+func opFnv(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	r, err := applyOne("fnv", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	s, err := hashInput("fnv", r)
+	if err != nil {
+		return nil, err
+	}
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return float64(h.Sum32()), nil
+}
@@ -0,0 +1,64 @@
+package ext
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestHashOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+	AddHashOps(jl)
+	jsonlogic.TestCases{
+		// bucket, results are cross-checked with the JavaScript implementation in the doc of AddHashOps.
+		{Logic: `{"bucket":["new-checkout",{"var":"user_id"}]}`, Data: `{"user_id":"user-42"}`, Result: 34.9},
+		{Logic: `{"bucket":["new-checkout","user-42",0]}`, Data: `null`, Result: float64(34)},
+		{Logic: `{"bucket":["new-checkout",{"var":"user_id"},4]}`, Data: `{"user_id":42}`, Result: 95.3299},
+		{Logic: `{"bucket":["exp","user-1"]}`, Data: `null`, Result: 92.65},
+		{Logic: `{"bucket":["exp2","user-1"]}`, Data: `null`, Result: 94.6},
+		{Logic: `{"<":[{"bucket":["new-checkout","user-42"]},50]}`, Data: `null`, Result: true},
+		{Logic: `{"bucket":["new-checkout",{"var":"user_id"}]}`, Data: `{}`, Err: true},
+		{Logic: `{"bucket":["new-checkout",[1]]}`, Data: `null`, Err: true},
+		{Logic: `{"bucket":["new-checkout","user-42",5]}`, Data: `null`, Err: true},
+		{Logic: `{"bucket":["new-checkout","user-42",1.5]}`, Data: `null`, Err: true},
+		{Logic: `{"bucket":["new-checkout"]}`, Data: `null`, Err: true},
+		// sha256.
+		{Logic: `{"sha256":"abc"}`, Data: `null`, Result: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{Logic: `{"sha256":""}`, Data: `null`, Result: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{Logic: `{"sha256":{"var":"id"}}`, Data: `{"id":123}`, Result: "a665a45920422f9d417e4867efdc4fb8a04a1f3fff1fa07e998e86f7f7a27ae3"},
+		{Logic: `{"sha256":null}`, Data: `null`, Err: true},
+		// murmur3.
+		{Logic: `{"murmur3":"hello"}`, Data: `null`, Result: float64(0x248bfa47)},
+		{Logic: `{"murmur3":["hello",1]}`, Data: `null`, Result: float64(3142237357)},
+		{Logic: `{"murmur3":["",1]}`, Data: `null`, Result: float64(0x514e28b7)},
+		{Logic: `{"murmur3":["Hello, world!",1234]}`, Data: `null`, Result: float64(0xfaf6cdb3)},
+		{Logic: `{"murmur3":"The quick brown fox jumps over the lazy dog"}`, Data: `null`, Result: float64(0x2e4ff723)},
+		{Logic: `{"murmur3":"新"}`, Data: `null`, Result: float64(2081120441)},
+		{Logic: `{"murmur3":["hello",-1]}`, Data: `null`, Err: true},
+		{Logic: `{"murmur3":["hello",4294967296]}`, Data: `null`, Err: true},
+		{Logic: `{"murmur3":true}`, Data: `null`, Err: true},
+		// fnv.
+		{Logic: `{"fnv":""}`, Data: `null`, Result: float64(0x811c9dc5)},
+		{Logic: `{"fnv":"a"}`, Data: `null`, Result: float64(3826002220)},
+		{Logic: `{"fnv":"新"}`, Data: `null`, Result: float64(2446905687)},
+		{Logic: `{"fnv":["a","b"]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
+
+func TestBucketDistribution(t *testing.T) {
+	assert := assert.New(t)
+	const n = 100000
+	counts := make([]int, 4)
+	for i := 0; i < n; i++ {
+		b := Bucket("seed", fmt.Sprint(i), 2)
+		assert.True(b >= 0 && b < 100)
+		counts[int(b/25)]++
+	}
+	for _, c := range counts {
+		assert.InDelta(n/4, c, n/100)
+	}
+}
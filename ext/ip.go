@@ -0,0 +1,247 @@
+package ext
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// DefaultCIDRCacheSize is the default max number of compiled CIDR lists kept in a CIDRCache.
+const DefaultCIDRCacheSize = 256
+
+// CIDRCache is a bounded LRU cache of parsed CIDR lists, so that a list in a rule (e.g. an office allowlist)
+// is parsed only once instead of in every evaluation. It is safe for concurrent use.
+type CIDRCache struct {
+	cache *lru
+}
+
+// NewCIDRCache creates a CIDRCache keeping at most size CIDR lists. Non-positive value means the default.
+func NewCIDRCache(size int) *CIDRCache {
+	if size <= 0 {
+		size = DefaultCIDRCacheSize
+	}
+	return &CIDRCache{
+		cache: newLRU(size),
+	}
+}
+
+// CIDRList is a parsed CIDR list.
+type CIDRList []*net.IPNet
+
+// Contains tests whether ip is in any network of the list.
+func (nets CIDRList) Contains(ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compile returns the parsed CIDR list from cache or parses it. Items can be CIDRs ("10.0.0.0/8",
+// "2001:db8::/32") or single IPs which are the same as "/32" or "/128" networks.
+func (cache *CIDRCache) Compile(cidrs []string) (CIDRList, error) {
+	key := strings.Join(cidrs, ",")
+	if nets, ok := cache.cache.get(key); ok {
+		return nets.(CIDRList), nil
+	}
+	nets := make(CIDRList, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		n, err := parseNetwork(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return cache.cache.add(key, nets).(CIDRList), nil
+}
+
+// Len returns the number of parsed CIDR lists in cache.
+func (cache *CIDRCache) Len() int {
+	return cache.cache.len()
+}
+
+// parseIP parses an IPv4 or IPv6 address, IPv4-mapped IPv6 addresses ("::ffff:1.2.3.4") are IPv4.
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP %q", s)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4, nil
+	}
+	return ip, nil
+}
+
+// parseNetwork parses a CIDR, or a single IP as a network of one address. Like parseIP, IPv4-mapped
+// networks (e.g. "::ffff:10.0.0.0/104") are converted to IPv4 ones.
+func parseNetwork(s string) (*net.IPNet, error) {
+	if strings.IndexByte(s, '/') < 0 {
+		ip, err := parseIP(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", s)
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}, nil
+	}
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q", s)
+	}
+	if ones, bits := n.Mask.Size(); bits == 8*net.IPv6len && ones >= 96 {
+		if ip4 := n.IP.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: n.Mask[12:]}, nil
+		}
+	}
+	return n, nil
+}
+
+// privateNets are IPv4 private networks (RFC 1918) and IPv6 unique local addresses (RFC 4193).
+var privateNets = func() CIDRList {
+	var nets CIDRList
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"} {
+		n, err := parseNetwork(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// AddIPOps adds "ip_in_cidr", "ip_version", "is_private_ip" and "cidr_contains" operations to the JSONLogic
+// instance, sharing the cache of parsed CIDR lists. If cache is nil, a new one with default settings is used.
+//
+// Both IPv4 and IPv6 are supported, IPv4-mapped IPv6 addresses and networks (e.g. "::ffff:10.1.2.3" and
+// "::ffff:10.0.0.0/104") are treated as IPv4.
+// Invalid IPs or CIDRs are evaluation errors, except for "ip_version".
+func AddIPOps(jl *jsonlogic.JSONLogic, cache *CIDRCache) {
+	if cache == nil {
+		cache = NewCIDRCache(0)
+	}
+	AddOpIPInCIDR(jl, cache)
+	AddOpIPVersion(jl)
+	AddOpIsPrivateIP(jl)
+	AddOpCIDRContains(jl)
+}
+
+// AddOpIPInCIDR adds "ip_in_cidr" operation to the JSONLogic instance, which tests whether an IP is in
+// a CIDR or any of an array of CIDRs (single IPs are allowed in the array too):
+//   - {"ip_in_cidr":[{"var":"client_ip"},"10.0.0.0/8"]}
+//   - {"ip_in_cidr":[{"var":"client_ip"},["10.0.0.0/8","203.0.113.7","2001:db8::/32"]]}
+func AddOpIPInCIDR(jl *jsonlogic.JSONLogic, cache *CIDRCache) {
+	jl.AddOperation("ip_in_cidr", func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		params, err := applyN("ip_in_cidr", apply, params, data, 2, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		s, ok := params[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("ip_in_cidr: expect string as IP but got %T", params[0])
+		}
+		ip, err := parseIP(s)
+		if err != nil {
+			return nil, fmt.Errorf("ip_in_cidr: %s", err.Error())
+		}
+
+		var cidrs []string
+		switch p := params[1].(type) {
+		case string:
+			cidrs = []string{p}
+		case []interface{}:
+			cidrs = make([]string, 0, len(p))
+			for _, item := range p {
+				cidr, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("ip_in_cidr: expect string as CIDR but got %T", item)
+				}
+				cidrs = append(cidrs, cidr)
+			}
+		default:
+			return nil, fmt.Errorf("ip_in_cidr: expect CIDR or array of CIDRs but got %T", params[1])
+		}
+		nets, err := cache.Compile(cidrs)
+		if err != nil {
+			return nil, fmt.Errorf("ip_in_cidr: %s", err.Error())
+		}
+		return nets.Contains(ip), nil
+	})
+}
+
+// AddOpIPVersion adds "ip_version" operation to the JSONLogic instance, which returns 4 or 6, or null if
+// the param is not a valid IP, so it can be used for validation:
+//   - {"ip_version":"10.1.2.3"} -> 4
+//   - {"ip_version":"2001:db8::1"} -> 6
+//   - {"ip_version":"10.1.2"} -> null
+func AddOpIPVersion(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("ip_version", opIPVersion)
+}
+
+func opIPVersion(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	r, err := applyOne("ip_version", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := r.(string)
+	if !ok {
+		return nil, nil
+	}
+	ip, err := parseIP(s)
+	if err != nil {
+		return nil, nil
+	}
+	if len(ip) == net.IPv4len {
+		return float64(4), nil
+	}
+	return float64(6), nil
+}
+
+// AddOpIsPrivateIP adds "is_private_ip" operation to the JSONLogic instance, which tests whether an IP is
+// in private networks: 10.0.0.0/8, 172.16.0.0/12, 192.168.0.0/16 (RFC 1918) and fc00::/7 (RFC 4193).
+// Loopback and link-local addresses are not private:
+//   - {"is_private_ip":"192.168.1.1"} -> true
+//   - {"is_private_ip":"127.0.0.1"} -> false
+func AddOpIsPrivateIP(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("is_private_ip", opIsPrivateIP)
+}
+
+func opIsPrivateIP(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ss, err := applyStrings("is_private_ip", 1, 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	ip, err := parseIP(ss[0])
+	if err != nil {
+		return nil, fmt.Errorf("is_private_ip: %s", err.Error())
+	}
+	return privateNets.Contains(ip), nil
+}
+
+// AddOpCIDRContains adds "cidr_contains" operation to the JSONLogic instance, which tests whether the first
+// CIDR contains the second CIDR (all of its addresses) or IP:
+//   - {"cidr_contains":["10.0.0.0/8","10.1.0.0/16"]} -> true
+//   - {"cidr_contains":["10.1.0.0/16","10.0.0.0/8"]} -> false
+//   - {"cidr_contains":["10.0.0.0/8","10.1.2.3"]} -> true
+func AddOpCIDRContains(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("cidr_contains", opCIDRContains)
+}
+
+func opCIDRContains(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ss, err := applyStrings("cidr_contains", 2, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	outer, err := parseNetwork(ss[0])
+	if err != nil {
+		return nil, fmt.Errorf("cidr_contains: %s", err.Error())
+	}
+	inner, err := parseNetwork(ss[1])
+	if err != nil {
+		return nil, fmt.Errorf("cidr_contains: %s", err.Error())
+	}
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP), nil
+}
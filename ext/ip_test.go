@@ -0,0 +1,92 @@
+package ext
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestIPOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+	AddIPOps(jl, nil)
+	jsonlogic.TestCases{
+		// ip_in_cidr.
+		{Logic: `{"ip_in_cidr":[{"var":"ip"},"10.0.0.0/8"]}`, Data: `{"ip":"10.1.2.3"}`, Result: true},
+		{Logic: `{"ip_in_cidr":[{"var":"ip"},"10.0.0.0/8"]}`, Data: `{"ip":"11.1.2.3"}`, Result: false},
+		{Logic: `{"ip_in_cidr":["::ffff:10.1.2.3","10.0.0.0/8"]}`, Data: `null`, Result: true},
+		{Logic: `{"ip_in_cidr":["10.1.2.3","::ffff:0:0/96"]}`, Data: `null`, Result: true},
+		{Logic: `{"ip_in_cidr":["::ffff:10.1.2.3","::ffff:10.0.0.0/104"]}`, Data: `null`, Result: true},
+		{Logic: `{"ip_in_cidr":["11.1.2.3","::ffff:10.0.0.0/104"]}`, Data: `null`, Result: false},
+		{Logic: `{"ip_in_cidr":["::ffff:10.1.2.3","::ffff:10.1.2.3"]}`, Data: `null`, Result: true},
+		{Logic: `{"ip_in_cidr":["2001:db8::1","::ffff:0:0/96"]}`, Data: `null`, Result: false},
+		{Logic: `{"ip_in_cidr":["2001:db8::1",["10.0.0.0/8","2001:db8::/32"]]}`, Data: `null`, Result: true},
+		{Logic: `{"ip_in_cidr":["203.0.113.7",["10.0.0.0/8","203.0.113.7"]]}`, Data: `null`, Result: true},
+		{Logic: `{"ip_in_cidr":["203.0.113.8",["10.0.0.0/8","203.0.113.7"]]}`, Data: `null`, Result: false},
+		{Logic: `{"ip_in_cidr":["10.1.2.3",[]]}`, Data: `null`, Result: false},
+		{Logic: `{"ip_in_cidr":["10.1.2.3","10.0.0.0/33"]}`, Data: `null`, Err: true},
+		{Logic: `{"ip_in_cidr":["10.1.2.3",["10.0.0.0/8",1]]}`, Data: `null`, Err: true},
+		{Logic: `{"ip_in_cidr":["10.1.2","10.0.0.0/8"]}`, Data: `null`, Err: true},
+		{Logic: `{"ip_in_cidr":[null,"10.0.0.0/8"]}`, Data: `null`, Err: true},
+		{Logic: `{"ip_in_cidr":["10.1.2.3"]}`, Data: `null`, Err: true},
+		// ip_version.
+		{Logic: `{"ip_version":"10.1.2.3"}`, Data: `null`, Result: float64(4)},
+		{Logic: `{"ip_version":"::ffff:10.1.2.3"}`, Data: `null`, Result: float64(4)},
+		{Logic: `{"ip_version":"2001:db8::1"}`, Data: `null`, Result: float64(6)},
+		{Logic: `{"ip_version":"10.1.2"}`, Data: `null`, Result: nil},
+		{Logic: `{"ip_version":1}`, Data: `null`, Result: nil},
+		{Logic: `{"ip_version":["10.1.2.3","10.1.2.3"]}`, Data: `null`, Err: true},
+		// is_private_ip.
+		{Logic: `{"is_private_ip":"10.255.0.1"}`, Data: `null`, Result: true},
+		{Logic: `{"is_private_ip":"172.31.0.1"}`, Data: `null`, Result: true},
+		{Logic: `{"is_private_ip":"172.32.0.1"}`, Data: `null`, Result: false},
+		{Logic: `{"is_private_ip":"192.168.1.1"}`, Data: `null`, Result: true},
+		{Logic: `{"is_private_ip":"127.0.0.1"}`, Data: `null`, Result: false},
+		{Logic: `{"is_private_ip":"fd12:3456::1"}`, Data: `null`, Result: true},
+		{Logic: `{"is_private_ip":"2001:db8::1"}`, Data: `null`, Result: false},
+		{Logic: `{"is_private_ip":"abc"}`, Data: `null`, Err: true},
+		// cidr_contains.
+		{Logic: `{"cidr_contains":["10.0.0.0/8","10.1.0.0/16"]}`, Data: `null`, Result: true},
+		{Logic: `{"cidr_contains":["10.0.0.0/8","10.0.0.0/8"]}`, Data: `null`, Result: true},
+		{Logic: `{"cidr_contains":["10.1.0.0/16","10.0.0.0/8"]}`, Data: `null`, Result: false},
+		{Logic: `{"cidr_contains":["10.0.0.0/8","10.1.2.3"]}`, Data: `null`, Result: true},
+		{Logic: `{"cidr_contains":["10.0.0.0/8","11.1.2.3"]}`, Data: `null`, Result: false},
+		{Logic: `{"cidr_contains":["2001:db8::/32","2001:db8:1::/48"]}`, Data: `null`, Result: true},
+		{Logic: `{"cidr_contains":["::/0","10.0.0.0/8"]}`, Data: `null`, Result: false},
+		{Logic: `{"cidr_contains":["::ffff:0:0/96","10.0.0.0/8"]}`, Data: `null`, Result: true},
+		{Logic: `{"cidr_contains":["10.0.0.0/8","::ffff:10.1.0.0/112"]}`, Data: `null`, Result: true},
+		{Logic: `{"cidr_contains":["10.0.0.0/8","10.0.0.0/x"]}`, Data: `null`, Err: true},
+		{Logic: `{"cidr_contains":["10.0.0.0/8"]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
+
+func TestCIDRCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := NewCIDRCache(2)
+
+	nets1, err := cache.Compile([]string{"10.0.0.0/8", "192.168.0.0/16"})
+	assert.NoError(err)
+	assert.Len(nets1, 2)
+	assert.True(nets1.Contains(net.ParseIP("192.168.1.1")))
+	assert.True(nets1.Contains(net.ParseIP("::ffff:10.1.2.3")))
+	assert.False(nets1.Contains(net.ParseIP("172.16.0.1")))
+	assert.Equal(1, cache.Len())
+
+	nets2, err := cache.Compile([]string{"10.0.0.0/8", "192.168.0.0/16"})
+	assert.NoError(err)
+	assert.True(&nets1[0] == &nets2[0])
+	assert.Equal(1, cache.Len())
+
+	_, err = cache.Compile([]string{"10.0.0.0/8"})
+	assert.NoError(err)
+	_, err = cache.Compile([]string{"192.168.0.0/16"})
+	assert.NoError(err)
+	assert.Equal(2, cache.Len())
+
+	_, err = cache.Compile([]string{"10.0.0.0/8", "x"})
+	assert.Error(err)
+	assert.Equal(2, cache.Len())
+}
@@ -0,0 +1,64 @@
+package ext
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lru is a bounded map with least recently used eviction, it is safe for concurrent use.
+type lru struct {
+	size int
+
+	mu      sync.Mutex
+	l       *list.List // of *lruEntry, most recently used at front
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value interface{}
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:    size,
+		l:       list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the value of key and marks it as the most recently used.
+func (c *lru) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.l.MoveToFront(elem)
+		return elem.Value.(*lruEntry).value, true
+	}
+	return nil, false
+}
+
+// add adds value of key unless it already exists, and returns the value in cache.
+func (c *lru) add(key string, value interface{}) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		// Added by others meanwhile.
+		c.l.MoveToFront(elem)
+		return elem.Value.(*lruEntry).value
+	}
+	c.entries[key] = c.l.PushFront(&lruEntry{key: key, value: value})
+	for c.l.Len() > c.size {
+		elem := c.l.Back()
+		c.l.Remove(elem)
+		delete(c.entries, elem.Value.(*lruEntry).key)
+	}
+	return value
+}
+
+// len returns the number of entries.
+func (c *lru) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.l.Len()
+}
@@ -0,0 +1,227 @@
+package ext
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// maxRoundDigits is the max absolute value of digits of "round".
+const maxRoundDigits = 15
+
+// AddMathOps adds all math operations in this file to the JSONLogic instance:
+// "abs", "floor", "ceil", "round", "trunc", "pow", "sqrt", "log", "exp" and "clamp".
+//
+// Like "+"/"-"/..., params are converted by jsonlogic.ToNumeric, and an error is returned instead of
+// NaN or +Inf/-Inf results (e.g. {"sqrt":-1} or {"log":0}).
+func AddMathOps(jl *jsonlogic.JSONLogic) {
+	AddOpAbs(jl)
+	AddOpFloor(jl)
+	AddOpCeil(jl)
+	AddOpRound(jl)
+	AddOpTrunc(jl)
+	AddOpPow(jl)
+	AddOpSqrt(jl)
+	AddOpLog(jl)
+	AddOpExp(jl)
+	AddOpClamp(jl)
+}
+
+// applyNumbers checks the number of params, evaluates them and converts them to numerics.
+func applyNumbers(name string, min, max int, apply jsonlogic.Applier, params []interface{}, data interface{}) ([]float64, error) {
+	params, err := applyN(name, apply, params, data, min, max)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]float64, 0, len(params))
+	for _, param := range params {
+		n, err := jsonlogic.ToNumeric(param)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		ret = append(ret, n)
+	}
+	return ret, nil
+}
+
+// numericResult returns an error if r is NaN or +Inf/-Inf.
+func numericResult(name string, r float64) (interface{}, error) {
+	if math.IsNaN(r) {
+		return nil, fmt.Errorf("%s: got NaN result", name)
+	}
+	if math.IsInf(r, 0) {
+		return nil, fmt.Errorf("%s: got -Inf/+Inf result", name)
+	}
+	return r, nil
+}
+
+// opMath1 returns an operation applying fn to its only param.
+func opMath1(name string, fn func(float64) float64) jsonlogic.Operation {
+	return func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		ns, err := applyNumbers(name, 1, 1, apply, params, data)
+		if err != nil {
+			return nil, err
+		}
+		return numericResult(name, fn(ns[0]))
+	}
+}
+
+// AddOpAbs adds "abs" operation to the JSONLogic instance:
+//   - {"abs":-1.5} -> 1.5
+func AddOpAbs(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("abs", opMath1("abs", math.Abs))
+}
+
+// AddOpFloor adds "floor" operation to the JSONLogic instance:
+//   - {"floor":-1.5} -> -2
+func AddOpFloor(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("floor", opMath1("floor", math.Floor))
+}
+
+// AddOpCeil adds "ceil" operation to the JSONLogic instance:
+//   - {"ceil":-1.5} -> -1
+func AddOpCeil(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("ceil", opMath1("ceil", math.Ceil))
+}
+
+// AddOpTrunc adds "trunc" operation to the JSONLogic instance, which removes the fractional part:
+//   - {"trunc":-1.5} -> -1
+func AddOpTrunc(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("trunc", opMath1("trunc", math.Trunc))
+}
+
+// AddOpSqrt adds "sqrt" operation to the JSONLogic instance:
+//   - {"sqrt":9} -> 3
+//   - {"sqrt":-1} -> error
+func AddOpSqrt(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("sqrt", opMath1("sqrt", math.Sqrt))
+}
+
+// AddOpExp adds "exp" operation to the JSONLogic instance, which returns e**x:
+//   - {"exp":0} -> 1
+func AddOpExp(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("exp", opMath1("exp", math.Exp))
+}
+
+// AddOpRound adds "round" operation to the JSONLogic instance. Params are the number, optional digits
+// after the decimal point (default 0, can be negative) and optional mode: "half_away" (default, rounds
+// half away from zero) or "half_even" (banker's rounding):
+//   - {"round":2.5} -> 3
+//   - {"round":-2.5} -> -3 (NOTE: JavaScript's Math.round gives -2)
+//   - {"round":[2.5,0,"half_even"]} -> 2
+//   - {"round":[1.2345,2]} -> 1.23
+//   - {"round":[1250,-2,"half_even"]} -> 1200
+//
+// Rounding works on the binary representation, so e.g. {"round":[1.005,2]} gives 1 like most languages.
+func AddOpRound(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("round", opRound)
+}
+
+func opRound(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, err := applyN("round", apply, params, data, 1, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := jsonlogic.ToNumeric(params[0])
+	if err != nil {
+		return nil, fmt.Errorf("round: %s", err.Error())
+	}
+	digits := float64(0)
+	if len(params) > 1 {
+		digits, err = jsonlogic.ToNumeric(params[1])
+		if err != nil {
+			return nil, fmt.Errorf("round: %s", err.Error())
+		}
+		if digits != math.Trunc(digits) || math.Abs(digits) > maxRoundDigits {
+			return nil, fmt.Errorf("round: expect integer digits in [-%d, %d] but got %v", maxRoundDigits, maxRoundDigits, digits)
+		}
+	}
+	round := math.Round
+	if len(params) > 2 {
+		switch params[2] {
+		case "half_away":
+		case "half_even":
+			round = math.RoundToEven
+		default:
+			return nil, fmt.Errorf("round: unknown mode %v", params[2])
+		}
+	}
+
+	if digits == 0 {
+		return numericResult("round", round(x))
+	}
+	p := math.Pow(10, math.Abs(digits))
+	if digits > 0 {
+		scaled := x * p
+		if math.IsInf(scaled, 0) {
+			// Too large to have fractional part.
+			return x, nil
+		}
+		return numericResult("round", round(scaled)/p)
+	}
+	return numericResult("round", round(x/p)*p)
+}
+
+// AddOpPow adds "pow" operation to the JSONLogic instance, which returns base**exp:
+//   - {"pow":[2,10]} -> 1024
+//   - {"pow":[-8,0.5]} -> error
+func AddOpPow(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("pow", opPow)
+}
+
+func opPow(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ns, err := applyNumbers("pow", 2, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return numericResult("pow", math.Pow(ns[0], ns[1]))
+}
+
+// AddOpLog adds "log" operation to the JSONLogic instance, which returns the natural logarithm, or the
+// logarithm in the optional base:
+//   - {"log":1} -> 0
+//   - {"log":[1000,10]} -> 3
+func AddOpLog(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("log", opLog)
+}
+
+func opLog(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ns, err := applyNumbers("log", 1, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(ns) == 1 {
+		return numericResult("log", math.Log(ns[0]))
+	}
+	switch base := ns[1]; base {
+	case 10:
+		return numericResult("log", math.Log10(ns[0]))
+	case 2:
+		return numericResult("log", math.Log2(ns[0]))
+	case 1:
+		return nil, fmt.Errorf("log: invalid base 1")
+	default:
+		return numericResult("log", math.Log(ns[0])/math.Log(base))
+	}
+}
+
+// AddOpClamp adds "clamp" operation to the JSONLogic instance, which limits a number to [min, max]:
+//   - {"clamp":[15,0,10]} -> 10
+//   - {"clamp":[-5,0,10]} -> 0
+func AddOpClamp(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("clamp", opClamp)
+}
+
+func opClamp(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ns, err := applyNumbers("clamp", 3, 3, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	x, lo, hi := ns[0], ns[1], ns[2]
+	if lo > hi {
+		return nil, fmt.Errorf("clamp: min %v > max %v", lo, hi)
+	}
+	return math.Max(lo, math.Min(x, hi)), nil
+}
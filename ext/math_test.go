@@ -0,0 +1,69 @@
+package ext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestMathOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.NewEmpty()
+	jsonlogic.AddOpVar(jl)
+	AddMathOps(jl)
+	jsonlogic.TestCases{
+		// abs/floor/ceil/trunc.
+		{Logic: `{"abs":-1.5}`, Data: `null`, Result: float64(1.5)},
+		{Logic: `{"abs":"-2"}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"abs":"x"}`, Data: `null`, Err: true},
+		{Logic: `{"abs":[[1]]}`, Data: `null`, Err: true},
+		{Logic: `{"abs":[1,2]}`, Data: `null`, Err: true},
+		{Logic: `{"floor":-1.5}`, Data: `null`, Result: float64(-2)},
+		{Logic: `{"floor":{"var":"a"}}`, Data: `{"a":1.9}`, Result: float64(1)},
+		{Logic: `{"ceil":-1.5}`, Data: `null`, Result: float64(-1)},
+		{Logic: `{"ceil":1.1}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"trunc":-1.5}`, Data: `null`, Result: float64(-1)},
+		{Logic: `{"trunc":null}`, Data: `null`, Result: float64(0)},
+		// round.
+		{Logic: `{"round":2.5}`, Data: `null`, Result: float64(3)},
+		{Logic: `{"round":-2.5}`, Data: `null`, Result: float64(-3)},
+		{Logic: `{"round":[2.5,0,"half_even"]}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"round":[3.5,0,"half_even"]}`, Data: `null`, Result: float64(4)},
+		{Logic: `{"round":[1.2345,2]}`, Data: `null`, Result: float64(1.23)},
+		{Logic: `{"round":[0.125,2,"half_even"]}`, Data: `null`, Result: float64(0.12)},
+		{Logic: `{"round":[0.125,2]}`, Data: `null`, Result: float64(0.13)},
+		{Logic: `{"round":[1250,-2,"half_even"]}`, Data: `null`, Result: float64(1200)},
+		{Logic: `{"round":[1250,-2]}`, Data: `null`, Result: float64(1300)},
+		{Logic: `{"round":[1e300,10]}`, Data: `null`, Result: float64(1e300)},
+		{Logic: `{"round":[1.5,0.5]}`, Data: `null`, Err: true},
+		{Logic: `{"round":[1.5,16]}`, Data: `null`, Err: true},
+		{Logic: `{"round":[1.5,0,"up"]}`, Data: `null`, Err: true},
+		{Logic: `{"round":[]}`, Data: `null`, Err: true},
+		// pow/sqrt/exp/log.
+		{Logic: `{"pow":[2,10]}`, Data: `null`, Result: float64(1024)},
+		{Logic: `{"pow":[4,-0.5]}`, Data: `null`, Result: float64(0.5)},
+		{Logic: `{"pow":[-8,0.5]}`, Data: `null`, Err: true},
+		{Logic: `{"pow":[10,400]}`, Data: `null`, Err: true},
+		{Logic: `{"pow":[0,-1]}`, Data: `null`, Err: true},
+		{Logic: `{"pow":[2]}`, Data: `null`, Err: true},
+		{Logic: `{"sqrt":9}`, Data: `null`, Result: float64(3)},
+		{Logic: `{"sqrt":-1}`, Data: `null`, Err: true},
+		{Logic: `{"exp":0}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"exp":1000}`, Data: `null`, Err: true},
+		{Logic: `{"log":1}`, Data: `null`, Result: float64(0)},
+		{Logic: `{"log":[1000,10]}`, Data: `null`, Result: float64(3)},
+		{Logic: `{"log":[8,2]}`, Data: `null`, Result: float64(3)},
+		{Logic: `{"log":[0.25,0.5]}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"log":0}`, Data: `null`, Err: true},
+		{Logic: `{"log":-1}`, Data: `null`, Err: true},
+		{Logic: `{"log":[5,1]}`, Data: `null`, Err: true},
+		// clamp.
+		{Logic: `{"clamp":[15,0,10]}`, Data: `null`, Result: float64(10)},
+		{Logic: `{"clamp":[-5,0,10]}`, Data: `null`, Result: float64(0)},
+		{Logic: `{"clamp":[5,0,10]}`, Data: `null`, Result: float64(5)},
+		{Logic: `{"clamp":[5,10,0]}`, Data: `null`, Err: true},
+		{Logic: `{"clamp":[5,0]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
@@ -0,0 +1,351 @@
+package ext
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// AddObjectOps adds all object operations in this file to the JSONLogic instance:
+// "keys", "values", "entries", "from_entries", "get", "has", "pick", "omit", "merge_objects", "object"
+// and "preserve".
+//
+// Object params evaluated to null are treated as empty objects, other non-objects are errors. Since Go maps
+// are unordered, "keys"/"values"/"entries" return items sorted by keys.
+//
+// NOTE: Objects with a single key are always treated as logic, use "object" to build them dynamically or
+// "preserve" to write them literally.
+func AddObjectOps(jl *jsonlogic.JSONLogic) {
+	AddOpKeys(jl)
+	AddOpValues(jl)
+	AddOpEntries(jl)
+	AddOpFromEntries(jl)
+	AddOpGet(jl)
+	AddOpHas(jl)
+	AddOpPick(jl)
+	AddOpOmit(jl)
+	AddOpMergeObjects(jl)
+	AddOpObject(jl)
+	AddOpPreserve(jl)
+}
+
+// toObject converts an evaluated param to object, null is treated as empty object.
+func toObject(name string, obj interface{}) (map[string]interface{}, error) {
+	switch o := obj.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return o, nil
+	default:
+		return nil, fmt.Errorf("%s: expect object but got %T", name, obj)
+	}
+}
+
+// applyObject checks the number of params (negative max means no limit), evaluates them and converts the first
+// one to object.
+func applyObject(name string, min, max int, apply jsonlogic.Applier, params []interface{}, data interface{}) (map[string]interface{}, []interface{}, error) {
+	params, err := applyN(name, apply, params, data, min, max)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, err := toObject(name, params[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return obj, params, nil
+}
+
+// sortedKeys returns keys of obj in order.
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// AddOpKeys adds "keys" operation to the JSONLogic instance, which returns sorted keys of an object:
+//   - {"keys":{"var":""}} -> ["a","b"] if data is {"b":2,"a":1}
+func AddOpKeys(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("keys", opKeys)
+}
+
+func opKeys(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	obj, _, err := applyObject("keys", 1, 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]interface{}, 0, len(obj))
+	for _, key := range sortedKeys(obj) {
+		ret = append(ret, key)
+	}
+	return ret, nil
+}
+
+// AddOpValues adds "values" operation to the JSONLogic instance, which returns values of an object in
+// the order of sorted keys:
+//   - {"values":{"var":""}} -> [1,2] if data is {"b":2,"a":1}
+func AddOpValues(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("values", opValues)
+}
+
+func opValues(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	obj, _, err := applyObject("values", 1, 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]interface{}, 0, len(obj))
+	for _, key := range sortedKeys(obj) {
+		ret = append(ret, obj[key])
+	}
+	return ret, nil
+}
+
+// AddOpEntries adds "entries" operation to the JSONLogic instance, which returns [key, value] pairs of
+// an object sorted by keys:
+//   - {"entries":{"var":""}} -> [["a",1],["b",2]] if data is {"b":2,"a":1}
+func AddOpEntries(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("entries", opEntries)
+}
+
+func opEntries(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	obj, _, err := applyObject("entries", 1, 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]interface{}, 0, len(obj))
+	for _, key := range sortedKeys(obj) {
+		ret = append(ret, []interface{}{key, obj[key]})
+	}
+	return ret, nil
+}
+
+// AddOpFromEntries adds "from_entries" operation to the JSONLogic instance, which builds an object from
+// [key, value] pairs, keys are converted by jsonlogic.ToString and later pairs win:
+//   - {"from_entries":[[["a",1],["b",2]]]} -> {"a":1,"b":2}
+//   - {"from_entries":{"map":[{"var":"users"},[{"var":"id"},{"var":"name"}]]}} -> names by id
+func AddOpFromEntries(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("from_entries", opFromEntries)
+}
+
+func opFromEntries(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	arr, err := applyArray("from_entries", 1, 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]interface{}, len(arr))
+	for _, item := range arr {
+		pair, ok := item.([]interface{})
+		if !ok || len(pair) != 2 {
+			return nil, fmt.Errorf("from_entries: expect [key, value] pairs but got %v", item)
+		}
+		key, err := jsonlogic.ToString(pair[0])
+		if err != nil {
+			return nil, fmt.Errorf("from_entries: %s", err.Error())
+		}
+		ret[key] = pair[1]
+	}
+	return ret, nil
+}
+
+// lookup gets the value of key in an object (key converted by jsonlogic.ToString) or an array (integer key,
+// negative counts from the end).
+func lookup(name string, container, key interface{}) (interface{}, bool, error) {
+	switch c := container.(type) {
+	case nil:
+		return nil, false, nil
+	case map[string]interface{}:
+		k, err := jsonlogic.ToString(key)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		v, ok := c[k]
+		return v, ok, nil
+	case []interface{}:
+		i, err := toIndex(name, key)
+		if err != nil {
+			return nil, false, err
+		}
+		if i < 0 {
+			i += len(c)
+		}
+		if i < 0 || i >= len(c) {
+			return nil, false, nil
+		}
+		return c[i], true, nil
+	default:
+		return nil, false, fmt.Errorf("%s: expect object or array but got %T", name, container)
+	}
+}
+
+// AddOpGet adds "get" operation to the JSONLogic instance, which returns the value of a dynamic key (not
+// a path like "var") in an object or an array, or the optional default (null if absent) if not found:
+//   - {"get":[{"var":"prices"},{"var":"currency"}]} -> price in the currency
+//   - {"get":[[1,2,3],-1]} -> 3
+//   - {"get":[{"var":"limits"},"x.y",0]} -> the value of key "x.y", or 0
+func AddOpGet(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("get", opGet)
+}
+
+func opGet(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, err := applyN("get", apply, params, data, 2, 3)
+	if err != nil {
+		return nil, err
+	}
+	v, ok, err := lookup("get", params[0], params[1])
+	if err != nil {
+		return nil, err
+	}
+	if !ok && len(params) > 2 {
+		return params[2], nil
+	}
+	return v, nil
+}
+
+// AddOpHas adds "has" operation to the JSONLogic instance, which tests whether an object or an array has
+// a key, the value can be null:
+//   - {"has":[{"var":""},"a"]} -> true if data is {"a":null}
+func AddOpHas(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("has", opHas)
+}
+
+func opHas(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, err := applyN("has", apply, params, data, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+	_, ok, err := lookup("has", params[0], params[1])
+	if err != nil {
+		return nil, err
+	}
+	return ok, nil
+}
+
+// keyList converts params (keys or arrays of keys) to a set of keys.
+func keyList(name string, params []interface{}) (map[string]struct{}, error) {
+	ret := make(map[string]struct{})
+	for _, param := range params {
+		items, ok := param.([]interface{})
+		if !ok {
+			items = []interface{}{param}
+		}
+		for _, item := range items {
+			key, err := jsonlogic.ToString(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err.Error())
+			}
+			ret[key] = struct{}{}
+		}
+	}
+	return ret, nil
+}
+
+// AddOpPick adds "pick" operation to the JSONLogic instance, which returns a new object with only the
+// given keys (params after the object, either keys or arrays of keys):
+//   - {"pick":[{"var":"user"},"id","name"]} -> {"id":...,"name":...}
+//   - {"pick":[{"var":"user"},["id","name"]]} -> the same
+func AddOpPick(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("pick", opPickOmit("pick", true))
+}
+
+// AddOpOmit adds "omit" operation to the JSONLogic instance, which is the opposite of "pick":
+//   - {"omit":[{"var":"user"},"password"]} -> user without password
+func AddOpOmit(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("omit", opPickOmit("omit", false))
+}
+
+func opPickOmit(name string, pick bool) jsonlogic.Operation {
+	return func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		obj, params, err := applyObject(name, 1, -1, apply, params, data)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := keyList(name, params[1:])
+		if err != nil {
+			return nil, err
+		}
+		ret := make(map[string]interface{})
+		for k, v := range obj {
+			if _, ok := keys[k]; ok == pick {
+				ret[k] = v
+			}
+		}
+		return ret, nil
+	}
+}
+
+// AddOpMergeObjects adds "merge_objects" operation to the JSONLogic instance, which shallowly merges
+// objects into a new one, later keys win and nulls are ignored:
+//   - {"merge_objects":[{"var":"defaults"},{"var":"overrides"}]}
+func AddOpMergeObjects(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("merge_objects", opMergeObjects)
+}
+
+func opMergeObjects(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, err := jsonlogic.ApplyParams(apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]interface{})
+	for _, param := range params {
+		obj, err := toObject("merge_objects", param)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range obj {
+			ret[k] = v
+		}
+	}
+	return ret, nil
+}
+
+// AddOpObject adds "object" operation to the JSONLogic instance, which builds an object from alternating
+// keys and values, both are evaluated and keys are converted by jsonlogic.ToString:
+//   - {"object":["a",1]} -> {"a":1}
+//   - {"object":["id",{"var":"user.id"},{"cat":["tag_",{"var":"t"}]},true]} -> {"id":...,"tag_x":true}
+func AddOpObject(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("object", opObject)
+}
+
+func opObject(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	if len(params)%2 != 0 {
+		return nil, fmt.Errorf("object: expect even number of params (key, value, ...)")
+	}
+	params, err := jsonlogic.ApplyParams(apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]interface{}, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		key, err := jsonlogic.ToString(params[i])
+		if err != nil {
+			return nil, fmt.Errorf("object: %s", err.Error())
+		}
+		ret[key] = params[i+1]
+	}
+	return ret, nil
+}
+
+// AddOpPreserve adds "preserve" operation to the JSONLogic instance, which returns its param literally
+// without evaluating it. Like other operations, a non-array param is the same as an array with one item,
+// so wrap arrays with one item in another array:
+//   - {"preserve":{"a":1}} -> {"a":1}
+//   - {"preserve":{"var":"x"}} -> {"var":"x"}
+//   - {"preserve":[1,2]} -> [1,2]
+//   - {"preserve":[[1]]} -> [1]
+func AddOpPreserve(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("preserve", opPreserve)
+	// The param is not logic.
+	jl.SetParamsValidator("preserve", func(validate func(logic interface{}) error, params []interface{}) error {
+		return nil
+	})
+}
+
+func opPreserve(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	if len(params) == 1 {
+		return params[0], nil
+	}
+	return params, nil
+}
@@ -0,0 +1,84 @@
+package ext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestObjectOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+	AddObjectOps(jl)
+	jsonlogic.TestCases{
+		// keys/values/entries.
+		{Logic: `{"keys":{"var":""}}`, Data: `{"b":2,"a":1}`, Result: []interface{}{"a", "b"}},
+		{Logic: `{"keys":{"var":"x"}}`, Data: `{}`, Result: []interface{}{}},
+		{Logic: `{"keys":[[1]]}`, Data: `null`, Err: true},
+		{Logic: `{"values":{"var":""}}`, Data: `{"b":2,"a":1}`, Result: []interface{}{float64(1), float64(2)}},
+		{Logic: `{"entries":{"var":""}}`, Data: `{"b":2,"a":1}`, Result: []interface{}{
+			[]interface{}{"a", float64(1)}, []interface{}{"b", float64(2)},
+		}},
+		// from_entries.
+		{Logic: `{"from_entries":[[["a",1],["b",2],["a",3]]]}`, Data: `null`, Result: map[string]interface{}{"a": float64(3), "b": float64(2)}},
+		{Logic: `{"from_entries":{"map":[{"var":"users"},[{"var":"id"},{"var":"name"}]]}}`, Data: `{"users":[{"id":1,"name":"x"},{"id":2,"name":"y"}]}`, Result: map[string]interface{}{"1": "x", "2": "y"}},
+		{Logic: `{"from_entries":{"entries":{"var":""}}}`, Data: `{"a":1}`, Result: map[string]interface{}{"a": float64(1)}},
+		{Logic: `{"from_entries":[[["a"]]]}`, Data: `null`, Err: true},
+		{Logic: `{"from_entries":[[[[1],1]]]}`, Data: `null`, Err: true},
+		// get/has.
+		{Logic: `{"get":[{"var":"prices"},{"var":"currency"}]}`, Data: `{"currency":"EUR","prices":{"EUR":9,"USD":10}}`, Result: float64(9)},
+		{Logic: `{"get":[{"var":"limits"},"x.y",0]}`, Data: `{"limits":{"x.y":5}}`, Result: float64(5)},
+		{Logic: `{"get":[{"var":"limits"},"x",0]}`, Data: `{"limits":{"x.y":5}}`, Result: float64(0)},
+		{Logic: `{"get":[{"var":"limits"},"x"]}`, Data: `{"limits":{}}`, Result: nil},
+		{Logic: `{"get":[[1,2,3],-1]}`, Data: `null`, Result: float64(3)},
+		{Logic: `{"get":[[1,2,3],"1"]}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"get":[[1,2,3],5,"none"]}`, Data: `null`, Result: "none"},
+		{Logic: `{"get":[null,"a"]}`, Data: `null`, Result: nil},
+		{Logic: `{"get":[[1],0.5]}`, Data: `null`, Err: true},
+		{Logic: `{"get":["abc",0]}`, Data: `null`, Err: true},
+		{Logic: `{"has":[{"var":""},"a"]}`, Data: `{"a":null}`, Result: true},
+		{Logic: `{"has":[{"var":""},"b"]}`, Data: `{"a":null}`, Result: false},
+		{Logic: `{"has":[[1,2],2]}`, Data: `null`, Result: false},
+		// pick/omit.
+		{Logic: `{"pick":[{"var":"user"},"id","name","x"]}`, Data: `{"user":{"id":1,"name":"a","password":"p"}}`, Result: map[string]interface{}{"id": float64(1), "name": "a"}},
+		{Logic: `{"pick":[{"var":"user"},["id","name"]]}`, Data: `{"user":{"id":1,"name":"a","password":"p"}}`, Result: map[string]interface{}{"id": float64(1), "name": "a"}},
+		{Logic: `{"pick":[{"var":"user"}]}`, Data: `{"user":{"id":1}}`, Result: map[string]interface{}{}},
+		{Logic: `{"omit":[{"var":"user"},"password"]}`, Data: `{"user":{"id":1,"name":"a","password":"p"}}`, Result: map[string]interface{}{"id": float64(1), "name": "a"}},
+		{Logic: `{"omit":[[1],"a"]}`, Data: `null`, Err: true},
+		{Logic: `{"omit":[]}`, Data: `null`, Err: true},
+		// merge_objects.
+		{Logic: `{"merge_objects":[{"var":"defaults"},{"var":"overrides"},null]}`, Data: `{"defaults":{"a":1,"b":2},"overrides":{"b":3}}`, Result: map[string]interface{}{"a": float64(1), "b": float64(3)}},
+		{Logic: `{"merge_objects":[]}`, Data: `null`, Result: map[string]interface{}{}},
+		{Logic: `{"merge_objects":[{"var":"a"},[1]]}`, Data: `{"a":{}}`, Err: true},
+		// object.
+		{Logic: `{"object":["a",1]}`, Data: `null`, Result: map[string]interface{}{"a": float64(1)}},
+		{Logic: `{"object":["id",{"var":"user.id"},{"cat":["tag_",{"var":"t"}]},true]}`, Data: `{"user":{"id":7},"t":"x"}`, Result: map[string]interface{}{"id": float64(7), "tag_x": true}},
+		{Logic: `{"object":[]}`, Data: `null`, Result: map[string]interface{}{}},
+		{Logic: `{"object":["a"]}`, Data: `null`, Err: true},
+		{Logic: `{"object":[[1],1]}`, Data: `null`, Err: true},
+		// preserve.
+		{Logic: `{"preserve":{"a":1}}`, Data: `null`, Result: map[string]interface{}{"a": float64(1)}},
+		{Logic: `{"preserve":{"var":"x"}}`, Data: `{"x":1}`, Result: map[string]interface{}{"var": "x"}},
+		{Logic: `{"preserve":[1,{"var":"x"}]}`, Data: `{"x":1}`, Result: []interface{}{float64(1), map[string]interface{}{"var": "x"}}},
+		{Logic: `{"preserve":[[1]]}`, Data: `null`, Result: []interface{}{float64(1)}},
+		{Logic: `{"merge_objects":[{"var":""},{"preserve":{"a":2}}]}`, Data: `{"a":1,"b":1}`, Result: map[string]interface{}{"a": float64(2), "b": float64(1)}},
+	}.Run(assert, jl)
+}
+
+func TestValidatePreserve(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+	AddObjectOps(jl)
+	for _, logic := range []interface{}{
+		map[string]interface{}{"preserve": map[string]interface{}{"a": 1.0}},
+		map[string]interface{}{"merge_objects": []interface{}{
+			map[string]interface{}{"var": ""},
+			map[string]interface{}{"preserve": map[string]interface{}{"unknown": 1.0}},
+		}},
+	} {
+		assert.NoError(jl.Validate(logic))
+	}
+	assert.Error(jl.Validate(map[string]interface{}{"merge_objects": map[string]interface{}{"unknown": 1.0}}))
+}
@@ -0,0 +1,161 @@
+package ext
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+const (
+	// DefaultRegexCacheSize is the default max number of compiled patterns kept in a RegexCache.
+	DefaultRegexCacheSize = 256
+	// DefaultRegexMaxPatternLength is the default max length (in bytes) of patterns.
+	DefaultRegexMaxPatternLength = 1024
+)
+
+// RegexCache is a bounded LRU cache of compiled regular expressions, it is safe for concurrent use.
+type RegexCache struct {
+	maxPatternLength int
+	cache            *lru
+}
+
+// NewRegexCache creates a RegexCache keeping at most size compiled patterns and rejecting patterns longer
+// than maxPatternLength bytes. Non-positive values mean the defaults.
+func NewRegexCache(size, maxPatternLength int) *RegexCache {
+	if size <= 0 {
+		size = DefaultRegexCacheSize
+	}
+	if maxPatternLength <= 0 {
+		maxPatternLength = DefaultRegexMaxPatternLength
+	}
+	return &RegexCache{
+		maxPatternLength: maxPatternLength,
+		cache:            newLRU(size),
+	}
+}
+
+// Compile returns the compiled pattern from cache or compiles it (RE2 syntax, see regexp/syntax).
+func (cache *RegexCache) Compile(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > cache.maxPatternLength {
+		return nil, fmt.Errorf("pattern too long (%d > %d)", len(pattern), cache.maxPatternLength)
+	}
+	if re, ok := cache.cache.get(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %s", pattern, err.Error())
+	}
+	return cache.cache.add(pattern, re).(*regexp.Regexp), nil
+}
+
+// Len returns the number of compiled patterns in cache.
+func (cache *RegexCache) Len() int {
+	return cache.cache.len()
+}
+
+// AddRegexOps adds "match", "regex_find" and "regex_replace" operations to the JSONLogic instance, sharing
+// the cache of compiled patterns. If cache is nil, a new one with default settings is used.
+//
+// Patterns are in RE2 syntax (Go's regexp package), which guarantees linear time matching, so it is safe
+// to use patterns from untrusted sources.
+func AddRegexOps(jl *jsonlogic.JSONLogic, cache *RegexCache) {
+	if cache == nil {
+		cache = NewRegexCache(0, 0)
+	}
+	AddOpMatch(jl, cache)
+	AddOpRegexFind(jl, cache)
+	AddOpRegexReplace(jl, cache)
+}
+
+// applyRegex evaluates params, converts them to strings and compiles the second one as pattern.
+func applyRegex(name string, min, max int, cache *RegexCache, apply jsonlogic.Applier, params []interface{}, data interface{}) ([]string, *regexp.Regexp, error) {
+	ss, err := applyStrings(name, min, max, apply, params, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	re, err := cache.Compile(ss[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", name, err.Error())
+	}
+	return ss, re, nil
+}
+
+// AddOpMatch adds "match" operation to the JSONLogic instance, which tests whether a string contains
+// any match of a pattern:
+//   - {"match":["abc@example.com","^[^@]+@[^@]+$"]} -> true
+func AddOpMatch(jl *jsonlogic.JSONLogic, cache *RegexCache) {
+	jl.AddOperation("match", func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		ss, re, err := applyRegex("match", 2, 2, cache, apply, params, data)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(ss[0]), nil
+	})
+}
+
+// AddOpRegexFind adds "regex_find" operation to the JSONLogic instance, which returns the first match of
+// a pattern in a string, or null if not found. An optional third param (group index or name) returns the
+// submatch of the group instead:
+//   - {"regex_find":["SKU-123-X","[0-9]+"]} -> "123"
+//   - {"regex_find":["SKU-123-X","SKU-([0-9]+)",1]} -> "123"
+//   - {"regex_find":["SKU-123-X","SKU-(?P<id>[0-9]+)","id"]} -> "123"
+func AddOpRegexFind(jl *jsonlogic.JSONLogic, cache *RegexCache) {
+	jl.AddOperation("regex_find", func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		if err := checkParams("regex_find", params, 2, 3); err != nil {
+			return nil, err
+		}
+		ss, re, err := applyRegex("regex_find", 2, 2, cache, apply, params[:2], data)
+		if err != nil {
+			return nil, err
+		}
+
+		group := 0
+		if len(params) > 2 {
+			r, err := apply(params[2], data)
+			if err != nil {
+				return nil, err
+			}
+			switch g := r.(type) {
+			case float64:
+				group = int(g)
+				if float64(group) != g || group < 0 || group > re.NumSubexp() {
+					return nil, fmt.Errorf("regex_find: group %v not found in pattern %q", g, re.String())
+				}
+			case string:
+				group = -1
+				for i, name := range re.SubexpNames() {
+					if name != "" && name == g {
+						group = i
+						break
+					}
+				}
+				if group < 0 {
+					return nil, fmt.Errorf("regex_find: group %q not found in pattern %q", g, re.String())
+				}
+			default:
+				return nil, fmt.Errorf("regex_find: expect number or string as group but got %T", r)
+			}
+		}
+
+		loc := re.FindStringSubmatchIndex(ss[0])
+		if loc == nil || loc[2*group] < 0 {
+			return nil, nil
+		}
+		return ss[0][loc[2*group]:loc[2*group+1]], nil
+	})
+}
+
+// AddOpRegexReplace adds "regex_replace" operation to the JSONLogic instance, which replaces all matches of a
+// pattern with a replacement, in which $1 or ${name} stands for the submatch (see regexp.Regexp.Expand):
+//   - {"regex_replace":["2020-01-02","(\\d+)-(\\d+)-(\\d+)","$3/$2/$1"]} -> "02/01/2020"
+func AddOpRegexReplace(jl *jsonlogic.JSONLogic, cache *RegexCache) {
+	jl.AddOperation("regex_replace", func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		ss, re, err := applyRegex("regex_replace", 3, 3, cache, apply, params, data)
+		if err != nil {
+			return nil, err
+		}
+		return re.ReplaceAllString(ss[0], ss[2]), nil
+	})
+}
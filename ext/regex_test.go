@@ -0,0 +1,94 @@
+package ext
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestRegexOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.NewEmpty()
+	jsonlogic.AddOpVar(jl)
+	AddRegexOps(jl, nil)
+	jsonlogic.TestCases{
+		// match.
+		{Logic: `{"match":["abc@example.com","^[^@]+@[^@]+$"]}`, Data: `null`, Result: true},
+		{Logic: `{"match":["abc","^[^@]+@[^@]+$"]}`, Data: `null`, Result: false},
+		{Logic: `{"match":[{"var":"sku"},"^SKU-[0-9]{3}$"]}`, Data: `{"sku":"SKU-123"}`, Result: true},
+		{Logic: `{"match":[123,"^\\d+$"]}`, Data: `null`, Result: true},
+		{Logic: `{"match":["你好","^.{2}$"]}`, Data: `null`, Result: true},
+		{Logic: `{"match":["abc","("]}`, Data: `null`, Err: true},
+		{Logic: `{"match":["abc","(?=a)"]}`, Data: `null`, Err: true}, // No lookahead in RE2.
+		{Logic: `{"match":[[],"a"]}`, Data: `null`, Err: true},
+		{Logic: `{"match":["abc"]}`, Data: `null`, Err: true},
+		// regex_find.
+		{Logic: `{"regex_find":["SKU-123-X","[0-9]+"]}`, Data: `null`, Result: "123"},
+		{Logic: `{"regex_find":["SKU-X","[0-9]+"]}`, Data: `null`, Result: nil},
+		{Logic: `{"regex_find":["SKU-123-X","SKU-([0-9]+)",1]}`, Data: `null`, Result: "123"},
+		{Logic: `{"regex_find":["SKU-123-X","SKU-(?P<id>[0-9]+)","id"]}`, Data: `null`, Result: "123"},
+		{Logic: `{"regex_find":["a","(a)|(b)",2]}`, Data: `null`, Result: nil},
+		{Logic: `{"regex_find":["SKU-123-X","SKU-([0-9]+)",2]}`, Data: `null`, Err: true},
+		{Logic: `{"regex_find":["SKU-123-X","SKU-([0-9]+)",0.5]}`, Data: `null`, Err: true},
+		{Logic: `{"regex_find":["SKU-123-X","SKU-([0-9]+)","id"]}`, Data: `null`, Err: true},
+		{Logic: `{"regex_find":["SKU-123-X","SKU-([0-9]+)",null]}`, Data: `null`, Err: true},
+		// regex_replace.
+		{Logic: `{"regex_replace":["2020-01-02","(\\d+)-(\\d+)-(\\d+)","$3/$2/$1"]}`, Data: `null`, Result: "02/01/2020"},
+		{Logic: `{"regex_replace":["a  b   c","\\s+"," "]}`, Data: `null`, Result: "a b c"},
+		{Logic: `{"regex_replace":["a","[","b"]}`, Data: `null`, Err: true},
+		{Logic: `{"regex_replace":["a","a"]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
+
+func TestRegexCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := NewRegexCache(2, 8)
+
+	re1, err := cache.Compile("a+")
+	assert.NoError(err)
+	re2, err := cache.Compile("a+")
+	assert.NoError(err)
+	assert.True(re1 == re2)
+	assert.Equal(1, cache.Len())
+
+	_, err = cache.Compile("b+")
+	assert.NoError(err)
+	// "a+" is the most recently used now.
+	_, err = cache.Compile("a+")
+	assert.NoError(err)
+	_, err = cache.Compile("c+")
+	assert.NoError(err)
+	assert.Equal(2, cache.Len())
+	re3, err := cache.Compile("a+")
+	assert.NoError(err)
+	assert.True(re1 == re3)
+
+	// Too long.
+	_, err = cache.Compile(strings.Repeat("a", 9))
+	assert.Error(err)
+
+	// Invalid pattern names the pattern.
+	_, err = cache.Compile("(")
+	assert.Error(err)
+	assert.Contains(err.Error(), `"("`)
+	assert.Equal(2, cache.Len())
+
+	// Shared by operations.
+	jl := jsonlogic.NewEmpty()
+	AddRegexOps(jl, cache)
+	for i := 0; i < 5; i++ {
+		_, err := jl.Apply(map[string]interface{}{
+			"match": []interface{}{"x", fmt.Sprintf("x%d", i)},
+		}, nil)
+		assert.NoError(err)
+	}
+	assert.Equal(2, cache.Len())
+	_, err = jl.Apply(map[string]interface{}{
+		"match": []interface{}{"x", strings.Repeat("x", 9)},
+	}, nil)
+	assert.Error(err)
+}
@@ -0,0 +1,457 @@
+package ext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// AddSemverOps adds "semver_compare" and "semver_satisfies" operations to the JSONLogic instance.
+//
+// Versions follow Semantic Versioning 2.0.0 (https://semver.org): MAJOR.MINOR.PATCH with optional
+// pre-release ("-beta.1") and build metadata ("+build.5", ignored in comparison), an optional leading "v" is
+// allowed. Invalid versions or ranges are evaluation errors.
+func AddSemverOps(jl *jsonlogic.JSONLogic) {
+	AddOpSemverCompare(jl)
+	AddOpSemverSatisfies(jl)
+}
+
+// semver is a parsed semantic version.
+type semver struct {
+	major, minor, patch uint64
+	pre                 []string
+}
+
+// parseNumericPart parses a numeric identifier without leading zeros.
+func parseNumericPart(s string) (uint64, bool) {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return 0, false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	return n, err == nil
+}
+
+// validIdentifier reports whether s is a valid pre-release (or build if build is true) identifier.
+func validIdentifier(s string, build bool) bool {
+	if s == "" {
+		return false
+	}
+	numeric := true
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-':
+			numeric = false
+		default:
+			return false
+		}
+	}
+	// Numeric pre-release identifiers must not have leading zeros.
+	return build || !numeric || len(s) == 1 || s[0] != '0'
+}
+
+// splitPreBuild splits "-pre+build" suffix off a version.
+func splitPreBuild(s string) (core string, pre []string, err error) {
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		for _, id := range strings.Split(s[i+1:], ".") {
+			if !validIdentifier(id, true) {
+				return "", nil, fmt.Errorf("invalid build metadata in %q", s)
+			}
+		}
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		pre = strings.Split(s[i+1:], ".")
+		for _, id := range pre {
+			if !validIdentifier(id, false) {
+				return "", nil, fmt.Errorf("invalid pre-release in %q", s)
+			}
+		}
+		s = s[:i]
+	}
+	return s, pre, nil
+}
+
+// parseSemver parses a full version.
+func parseSemver(s string) (semver, error) {
+	orig := s
+	s = strings.TrimPrefix(s, "v")
+	core, pre, err := splitPreBuild(s)
+	if err != nil {
+		return semver{}, fmt.Errorf("invalid version %q", orig)
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return semver{}, fmt.Errorf("invalid version %q", orig)
+	}
+	var nums [3]uint64
+	for i, part := range parts {
+		n, ok := parseNumericPart(part)
+		if !ok {
+			return semver{}, fmt.Errorf("invalid version %q", orig)
+		}
+		nums[i] = n
+	}
+	return semver{major: nums[0], minor: nums[1], patch: nums[2], pre: pre}, nil
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareSemver compares versions by precedence (see https://semver.org/#spec-item-11).
+func compareSemver(a, b semver) int {
+	if c := compareUint(a.major, b.major); c != 0 {
+		return c
+	}
+	if c := compareUint(a.minor, b.minor); c != 0 {
+		return c
+	}
+	if c := compareUint(a.patch, b.patch); c != 0 {
+		return c
+	}
+	// A version without pre-release has higher precedence.
+	switch {
+	case len(a.pre) == 0 && len(b.pre) == 0:
+		return 0
+	case len(a.pre) == 0:
+		return 1
+	case len(b.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.pre) && i < len(b.pre); i++ {
+		x, y := a.pre[i], b.pre[i]
+		xn, xNumeric := parseNumericPart(x)
+		yn, yNumeric := parseNumericPart(y)
+		var c int
+		switch {
+		case xNumeric && yNumeric:
+			c = compareUint(xn, yn)
+		case xNumeric:
+			// Numeric identifiers have lower precedence.
+			c = -1
+		case yNumeric:
+			c = 1
+		default:
+			c = strings.Compare(x, y)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(a.pre)), uint64(len(b.pre)))
+}
+
+// comparator is a primitive constraint of a range, op is one of "=", ">", ">=", "<" and "<=".
+type comparator struct {
+	op string
+	v  semver
+}
+
+func (c comparator) test(v semver) bool {
+	r := compareSemver(v, c.v)
+	switch c.op {
+	case "=":
+		return r == 0
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	case "<":
+		return r < 0
+	default:
+		return r <= 0
+	}
+}
+
+// partial is a possibly partial version in ranges like "1", "1.2", "1.x" or "*", n is the number of
+// specified (non-wildcard) parts.
+type partial struct {
+	n int
+	v semver
+}
+
+func parsePartial(s string) (partial, error) {
+	orig := s
+	s = strings.TrimPrefix(s, "v")
+	core, pre, err := splitPreBuild(s)
+	if err != nil {
+		return partial{}, fmt.Errorf("invalid version %q", orig)
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return partial{}, fmt.Errorf("invalid version %q", orig)
+	}
+	var (
+		p    partial
+		nums [3]uint64
+	)
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, ok := parseNumericPart(part)
+		if !ok || p.n != i {
+			return partial{}, fmt.Errorf("invalid version %q", orig)
+		}
+		nums[i] = n
+		p.n++
+	}
+	if p.n < len(parts) {
+		// Everything after a wildcard must be wildcard too.
+		for _, part := range parts[p.n:] {
+			if part != "x" && part != "X" && part != "*" {
+				return partial{}, fmt.Errorf("invalid version %q", orig)
+			}
+		}
+	}
+	if len(pre) > 0 && p.n != 3 {
+		return partial{}, fmt.Errorf("invalid version %q", orig)
+	}
+	p.v = semver{major: nums[0], minor: nums[1], patch: nums[2], pre: pre}
+	return p, nil
+}
+
+// next returns the smallest version greater than all versions matched by the partial.
+func (p partial) next() semver {
+	switch p.n {
+	case 1:
+		return semver{major: p.v.major + 1}
+	case 2:
+		return semver{major: p.v.major, minor: p.v.minor + 1}
+	default:
+		return semver{major: p.v.major, minor: p.v.minor, patch: p.v.patch + 1}
+	}
+}
+
+// parseComparators parses a space separated set of comparators (which are ANDed).
+func parseComparators(s string) ([]comparator, error) {
+	fields := strings.Fields(s)
+	ret := []comparator{}
+
+	// Hyphen range "a - b".
+	if len(fields) == 3 && fields[1] == "-" {
+		lo, err := parsePartial(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		hi, err := parsePartial(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		if lo.n > 0 {
+			ret = append(ret, comparator{">=", lo.v})
+		}
+		switch {
+		case hi.n == 3:
+			ret = append(ret, comparator{"<=", hi.v})
+		case hi.n > 0:
+			ret = append(ret, comparator{"<", hi.next()})
+		}
+		return ret, nil
+	}
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		op := ""
+		for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(field, prefix) {
+				op = prefix
+				break
+			}
+		}
+		ver := field[len(op):]
+		if ver == "" && i+1 < len(fields) {
+			// Allow space after operator, e.g. ">= 1.2.3".
+			i++
+			ver = fields[i]
+		}
+		p, err := parsePartial(ver)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case "", "=":
+			if p.n == 3 {
+				ret = append(ret, comparator{"=", p.v})
+			} else if p.n > 0 {
+				ret = append(ret, comparator{">=", p.v}, comparator{"<", p.next()})
+			}
+		case ">=":
+			ret = append(ret, comparator{">=", p.v})
+		case "<":
+			ret = append(ret, comparator{"<", p.v})
+		case ">":
+			if p.n == 3 {
+				ret = append(ret, comparator{">", p.v})
+			} else if p.n > 0 {
+				ret = append(ret, comparator{">=", p.next()})
+			} else {
+				// Nothing is greater than "*".
+				ret = append(ret, comparator{"<", semver{}})
+			}
+		case "<=":
+			if p.n == 3 {
+				ret = append(ret, comparator{"<=", p.v})
+			} else if p.n > 0 {
+				ret = append(ret, comparator{"<", p.next()})
+			}
+		case "~":
+			// Allow patch-level changes if minor is specified, minor-level changes if not.
+			n := p.n
+			if n == 3 {
+				n = 2
+			}
+			ret = append(ret, comparator{">=", p.v})
+			if n > 0 {
+				ret = append(ret, comparator{"<", partial{n: n, v: p.v}.next()})
+			}
+		case "^":
+			// Allow changes that do not modify the left-most non-zero part.
+			var n int
+			switch {
+			case p.v.major != 0 || p.n == 1:
+				n = 1
+			case p.v.minor != 0 || p.n == 2:
+				n = 2
+			default:
+				n = 3
+			}
+			ret = append(ret, comparator{">=", p.v})
+			if p.n > 0 {
+				ret = append(ret, comparator{"<", partial{n: n, v: p.v}.next()})
+			}
+		}
+	}
+	return ret, nil
+}
+
+// semverRange is a parsed range: sets of comparators ORed by "||".
+type semverRange [][]comparator
+
+func parseSemverRange(s string) (semverRange, error) {
+	ret := semverRange{}
+	for _, set := range strings.Split(s, "||") {
+		cs, err := parseComparators(set)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q: %s", s, err.Error())
+		}
+		ret = append(ret, cs)
+	}
+	return ret, nil
+}
+
+// test reports whether v satisfies the range. Like npm's semver, a version with pre-release only satisfies a
+// comparator set if some comparator in it has the same MAJOR.MINOR.PATCH and a pre-release too, unless
+// includePrerelease is true.
+func (r semverRange) test(v semver, includePrerelease bool) bool {
+	for _, set := range r {
+		ok := true
+		for _, c := range set {
+			if !c.test(v) {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		if len(v.pre) == 0 || includePrerelease {
+			return true
+		}
+		for _, c := range set {
+			if len(c.v.pre) > 0 && c.v.major == v.major && c.v.minor == v.minor && c.v.patch == v.patch {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddOpSemverCompare adds "semver_compare" operation to the JSONLogic instance, which returns -1, 0 or 1 if
+// the first version is lower than, equal to or greater than the second:
+//   - {"semver_compare":["1.10.0","1.9.0"]} -> 1
+//   - {"semver_compare":["1.0.0-rc.1","1.0.0"]} -> -1
+//   - {"semver_compare":["1.0.0+build.1","v1.0.0"]} -> 0
+func AddOpSemverCompare(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("semver_compare", opSemverCompare)
+}
+
+func opSemverCompare(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ss, err := applyStrings("semver_compare", 2, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	a, err := parseSemver(ss[0])
+	if err != nil {
+		return nil, fmt.Errorf("semver_compare: %s", err.Error())
+	}
+	b, err := parseSemver(ss[1])
+	if err != nil {
+		return nil, fmt.Errorf("semver_compare: %s", err.Error())
+	}
+	return float64(compareSemver(a, b)), nil
+}
+
+// AddOpSemverSatisfies adds "semver_satisfies" operation to the JSONLogic instance, which tests whether a
+// version satisfies a range. Ranges use the syntax of npm's semver package:
+//   - Comparators ">=1.2.0", "<2.0.0", "=1.2.3" (or just "1.2.3") separated by spaces are ANDed.
+//   - Comparator sets separated by "||" are ORed.
+//   - Partial versions "1.2", "1.x", "*": "1.2" is ">=1.2.0 <1.3.0" and ">1.2" is ">=1.3.0".
+//   - Hyphen ranges: "1.2.3 - 2.3" is ">=1.2.3 <2.4.0".
+//   - Tilde ranges: "~1.2.3" is ">=1.2.3 <1.3.0" and "~1" is ">=1.0.0 <2.0.0".
+//   - Caret ranges: "^1.4" is ">=1.4.0 <2.0.0", "^0.2.3" is ">=0.2.3 <0.3.0" and "^0.0.3" is ">=0.0.3 <0.0.4".
+//
+// A version with pre-release (e.g. "1.3.0-beta") only satisfies a comparator set if some comparator in it
+// has a pre-release on the same MAJOR.MINOR.PATCH (e.g. ">=1.3.0-alpha"), unless the optional third param is
+// true. Examples:
+//   - {"semver_satisfies":[{"var":"app_version"},">=1.2.0 <2.0.0"]}
+//   - {"semver_satisfies":["1.4.2","^1.4"]} -> true
+//   - {"semver_satisfies":["2.0.0-beta","^1.4 || >=2.0.0-alpha"]} -> true
+//   - {"semver_satisfies":["1.5.0-beta","^1.4"]} -> false
+//   - {"semver_satisfies":["1.5.0-beta","^1.4",true]} -> true
+func AddOpSemverSatisfies(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("semver_satisfies", opSemverSatisfies)
+}
+
+func opSemverSatisfies(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	if err := checkParams("semver_satisfies", params, 2, 3); err != nil {
+		return nil, err
+	}
+	ss, err := applyStrings("semver_satisfies", 2, 2, apply, params[:2], data)
+	if err != nil {
+		return nil, err
+	}
+	includePrerelease := false
+	if len(params) > 2 {
+		r, err := apply(params[2], data)
+		if err != nil {
+			return nil, err
+		}
+		includePrerelease = jsonlogic.ToBool(r)
+	}
+
+	v, err := parseSemver(ss[0])
+	if err != nil {
+		return nil, fmt.Errorf("semver_satisfies: %s", err.Error())
+	}
+	r, err := parseSemverRange(ss[1])
+	if err != nil {
+		return nil, fmt.Errorf("semver_satisfies: %s", err.Error())
+	}
+	return r.test(v, includePrerelease), nil
+}
@@ -0,0 +1,75 @@
+package ext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestSemverOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+	AddSemverOps(jl)
+	jsonlogic.TestCases{
+		// semver_compare.
+		{Logic: `{"semver_compare":["1.10.0","1.9.0"]}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"semver_compare":["1.9.0","1.10.0"]}`, Data: `null`, Result: float64(-1)},
+		{Logic: `{"semver_compare":["1.0.0+build.1","v1.0.0"]}`, Data: `null`, Result: float64(0)},
+		{Logic: `{"semver_compare":["1.0.0-rc.1","1.0.0"]}`, Data: `null`, Result: float64(-1)},
+		// Precedence example from semver.org.
+		{Logic: `{"semver_compare":["1.0.0-alpha","1.0.0-alpha.1"]}`, Data: `null`, Result: float64(-1)},
+		{Logic: `{"semver_compare":["1.0.0-alpha.1","1.0.0-alpha.beta"]}`, Data: `null`, Result: float64(-1)},
+		{Logic: `{"semver_compare":["1.0.0-alpha.beta","1.0.0-beta"]}`, Data: `null`, Result: float64(-1)},
+		{Logic: `{"semver_compare":["1.0.0-beta","1.0.0-beta.2"]}`, Data: `null`, Result: float64(-1)},
+		{Logic: `{"semver_compare":["1.0.0-beta.2","1.0.0-beta.11"]}`, Data: `null`, Result: float64(-1)},
+		{Logic: `{"semver_compare":["1.0.0-beta.11","1.0.0-rc.1"]}`, Data: `null`, Result: float64(-1)},
+		{Logic: `{"semver_compare":[{"var":"v"},"2.0.0"]}`, Data: `{"v":"2.0.0"}`, Result: float64(0)},
+		{Logic: `{"semver_compare":["1.0","1.0.0"]}`, Data: `null`, Err: true},
+		{Logic: `{"semver_compare":["01.0.0","1.0.0"]}`, Data: `null`, Err: true},
+		{Logic: `{"semver_compare":["1.0.0-01","1.0.0"]}`, Data: `null`, Err: true},
+		{Logic: `{"semver_compare":["1.0.0-a..b","1.0.0"]}`, Data: `null`, Err: true},
+		{Logic: `{"semver_compare":["1.0.0+","1.0.0"]}`, Data: `null`, Err: true},
+		{Logic: `{"semver_compare":["abc","1.0.0"]}`, Data: `null`, Err: true},
+		{Logic: `{"semver_compare":["1.0.0"]}`, Data: `null`, Err: true},
+		// semver_satisfies.
+		{Logic: `{"semver_satisfies":[{"var":"v"},">=1.2.0 <2.0.0"]}`, Data: `{"v":"1.10.0"}`, Result: true},
+		{Logic: `{"semver_satisfies":[{"var":"v"},">=1.2.0 <2.0.0"]}`, Data: `{"v":"2.0.0"}`, Result: false},
+		{Logic: `{"semver_satisfies":[{"var":"v"},">= 1.2.0 < 2.0.0"]}`, Data: `{"v":"1.2.0"}`, Result: true},
+		{Logic: `{"semver_satisfies":["1.4.2","^1.4"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["1.3.9","^1.4"]}`, Data: `null`, Result: false},
+		{Logic: `{"semver_satisfies":["2.0.0","^1.4"]}`, Data: `null`, Result: false},
+		{Logic: `{"semver_satisfies":["0.2.9","^0.2.3"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["0.3.0","^0.2.3"]}`, Data: `null`, Result: false},
+		{Logic: `{"semver_satisfies":["0.0.4","^0.0.3"]}`, Data: `null`, Result: false},
+		{Logic: `{"semver_satisfies":["0.9.0","^0.x"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["1.2.9","~1.2.3"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["1.3.0","~1.2.3"]}`, Data: `null`, Result: false},
+		{Logic: `{"semver_satisfies":["1.9.0","~1"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["1.2.7","1.2"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["1.3.0","1.2.x"]}`, Data: `null`, Result: false},
+		{Logic: `{"semver_satisfies":["1.3.0",">1.2"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["1.2.9",">1.2"]}`, Data: `null`, Result: false},
+		{Logic: `{"semver_satisfies":["1.2.9","<=1.2"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["1.2.3","1.2.3"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["1.2.4","=1.2.3"]}`, Data: `null`, Result: false},
+		{Logic: `{"semver_satisfies":["2.3.9","1.2.3 - 2.3"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["2.4.0","1.2.3 - 2.3"]}`, Data: `null`, Result: false},
+		{Logic: `{"semver_satisfies":["3.0.0","^1.4 || ^3"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["5.0.0","*"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["5.0.0",""]}`, Data: `null`, Result: true},
+		// Pre-releases.
+		{Logic: `{"semver_satisfies":["1.5.0-beta","^1.4"]}`, Data: `null`, Result: false},
+		{Logic: `{"semver_satisfies":["1.5.0-beta","^1.4",true]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["2.0.0-beta","^1.4 || >=2.0.0-alpha"]}`, Data: `null`, Result: true},
+		{Logic: `{"semver_satisfies":["2.0.1-beta",">=2.0.0-alpha"]}`, Data: `null`, Result: false},
+		{Logic: `{"semver_satisfies":["1.2.3-alpha.2","^1.2.3-alpha.1"]}`, Data: `null`, Result: true},
+		// Invalid.
+		{Logic: `{"semver_satisfies":["1.2","^1.4"]}`, Data: `null`, Err: true},
+		{Logic: `{"semver_satisfies":["1.2.3","^1.x.2"]}`, Data: `null`, Err: true},
+		{Logic: `{"semver_satisfies":["1.2.3",">=abc"]}`, Data: `null`, Err: true},
+		{Logic: `{"semver_satisfies":["1.2.3","1.2.3.4"]}`, Data: `null`, Err: true},
+		{Logic: `{"semver_satisfies":["1.2.3"]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
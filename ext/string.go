@@ -0,0 +1,293 @@
+package ext
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// maxPadLength is the max length of padding of "pad_start"/"pad_end" to avoid huge allocation.
+const maxPadLength = 1 << 20
+
+// AddStringOps adds all string operations in this file to the JSONLogic instance:
+// "upper", "lower", "trim", "split", "join", "replace", "starts_with", "ends_with", "length"
+// (see AddOpLength, which also counts items of arrays), "pad_start" and "pad_end".
+//
+// Like "cat"/"substr", string params are converted by jsonlogic.ToString so an error is returned
+// for non-primitives, and all lengths/positions count in unicode code points (runes).
+func AddStringOps(jl *jsonlogic.JSONLogic) {
+	AddOpUpper(jl)
+	AddOpLower(jl)
+	AddOpTrim(jl)
+	AddOpSplit(jl)
+	AddOpJoin(jl)
+	AddOpReplace(jl)
+	AddOpStartsWith(jl)
+	AddOpEndsWith(jl)
+	AddOpLength(jl)
+	AddOpPadStart(jl)
+	AddOpPadEnd(jl)
+}
+
+// applyStrings checks the number of params, evaluates them and converts them to strings.
+func applyStrings(name string, min, max int, apply jsonlogic.Applier, params []interface{}, data interface{}) ([]string, error) {
+	params, err := applyN(name, apply, params, data, min, max)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0, len(params))
+	for _, param := range params {
+		s, err := jsonlogic.ToString(param)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		ret = append(ret, s)
+	}
+	return ret, nil
+}
+
+// AddOpUpper adds "upper" operation to the JSONLogic instance, which converts a string to upper case:
+//   - {"upper":"abc"} -> "ABC"
+func AddOpUpper(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("upper", opUpper)
+}
+
+func opUpper(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ss, err := applyStrings("upper", 1, 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToUpper(ss[0]), nil
+}
+
+// AddOpLower adds "lower" operation to the JSONLogic instance, which converts a string to lower case:
+//   - {"lower":"ABC"} -> "abc"
+func AddOpLower(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("lower", opLower)
+}
+
+func opLower(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ss, err := applyStrings("lower", 1, 1, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToLower(ss[0]), nil
+}
+
+// AddOpTrim adds "trim" operation to the JSONLogic instance, which removes leading and trailing white
+// spaces, or characters in the optional second param:
+//   - {"trim":"  abc "} -> "abc"
+//   - {"trim":["xxabcx","x"]} -> "abc"
+func AddOpTrim(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("trim", opTrim)
+}
+
+func opTrim(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ss, err := applyStrings("trim", 1, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(ss) == 1 {
+		return strings.TrimSpace(ss[0]), nil
+	}
+	return strings.Trim(ss[0], ss[1]), nil
+}
+
+// AddOpSplit adds "split" operation to the JSONLogic instance, which splits a string by a separator into
+// an array of strings. An empty separator splits the string into characters. An optional third integer param limits
+// the number of parts, the last part is the unsplit remainder:
+//   - {"split":["a,b,c",","]} -> ["a","b","c"]
+//   - {"split":["a,b,c",",",2]} -> ["a","b,c"]
+//   - {"split":["你好",""]} -> ["你","好"]
+func AddOpSplit(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("split", opSplit)
+}
+
+func opSplit(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	if err := checkParams("split", params, 2, 3); err != nil {
+		return nil, err
+	}
+	ss, err := applyStrings("split", 2, 2, apply, params[:2], data)
+	if err != nil {
+		return nil, err
+	}
+
+	n := -1
+	if len(params) > 2 {
+		r, err := apply(params[2], data)
+		if err != nil {
+			return nil, err
+		}
+		n, err = toIndex("split", r)
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 {
+			return nil, fmt.Errorf("split: expect positive limit but got %d", n)
+		}
+	}
+
+	parts := strings.SplitN(ss[0], ss[1], n)
+	ret := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		ret = append(ret, part)
+	}
+	return ret, nil
+}
+
+// AddOpJoin adds "join" operation to the JSONLogic instance, which joins items of an array (converted by
+// jsonlogic.ToString) with an optional separator (default ""):
+//   - {"join":[["a","b",1],"-"]} -> "a-b-1"
+func AddOpJoin(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("join", opJoin)
+}
+
+func opJoin(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, err := applyN("join", apply, params, data, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	arr, ok := params[0].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("join: expect array for param 0 but got %T", params[0])
+	}
+	sep := ""
+	if len(params) > 1 {
+		sep, err = jsonlogic.ToString(params[1])
+		if err != nil {
+			return nil, fmt.Errorf("join: %s", err.Error())
+		}
+	}
+
+	parts := make([]string, 0, len(arr))
+	for _, item := range arr {
+		s, err := jsonlogic.ToString(item)
+		if err != nil {
+			return nil, fmt.Errorf("join: %s", err.Error())
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// AddOpReplace adds "replace" operation to the JSONLogic instance, which replaces occurrences of a
+// substring. An optional fourth integer param limits the number of replacements (default all):
+//   - {"replace":["a-b-c","-","+"]} -> "a+b+c"
+//   - {"replace":["a-b-c","-","+",1]} -> "a+b-c"
+func AddOpReplace(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("replace", opReplace)
+}
+
+func opReplace(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	if err := checkParams("replace", params, 3, 4); err != nil {
+		return nil, err
+	}
+	ss, err := applyStrings("replace", 3, 3, apply, params[:3], data)
+	if err != nil {
+		return nil, err
+	}
+
+	n := -1
+	if len(params) > 3 {
+		r, err := apply(params[3], data)
+		if err != nil {
+			return nil, err
+		}
+		n, err = toIndex("replace", r)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("replace: expect non-negative limit but got %d", n)
+		}
+	}
+	return strings.Replace(ss[0], ss[1], ss[2], n), nil
+}
+
+// AddOpStartsWith adds "starts_with" operation to the JSONLogic instance:
+//   - {"starts_with":["abc","ab"]} -> true
+func AddOpStartsWith(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("starts_with", opStartsWith)
+}
+
+func opStartsWith(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ss, err := applyStrings("starts_with", 2, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasPrefix(ss[0], ss[1]), nil
+}
+
+// AddOpEndsWith adds "ends_with" operation to the JSONLogic instance:
+//   - {"ends_with":["abc","bc"]} -> true
+func AddOpEndsWith(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("ends_with", opEndsWith)
+}
+
+func opEndsWith(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	ss, err := applyStrings("ends_with", 2, 2, apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasSuffix(ss[0], ss[1]), nil
+}
+
+// AddOpPadStart adds "pad_start" operation to the JSONLogic instance, which pads a string at the start
+// with an optional pad string (default " ") until it reaches the given integer length, like JavaScript's padStart:
+//   - {"pad_start":["5",3,"0"]} -> "005"
+//   - {"pad_start":["abc",6,"12"]} -> "121abc"
+func AddOpPadStart(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("pad_start", opPad("pad_start", true))
+}
+
+// AddOpPadEnd adds "pad_end" operation to the JSONLogic instance, which is the same as "pad_start" but
+// pads at the end:
+//   - {"pad_end":["5",3,"0"]} -> "500"
+func AddOpPadEnd(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("pad_end", opPad("pad_end", false))
+}
+
+func opPad(name string, atStart bool) jsonlogic.Operation {
+	return func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		params, err := applyN(name, apply, params, data, 2, 3)
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := jsonlogic.ToString(params[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		length, err := toIndex(name, params[1])
+		if err != nil {
+			return nil, err
+		}
+		pad := " "
+		if len(params) > 2 {
+			pad, err = jsonlogic.ToString(params[2])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err.Error())
+			}
+		}
+
+		padLen := length - utf8.RuneCountInString(s)
+		if padLen <= 0 || pad == "" {
+			return s, nil
+		}
+		if padLen > maxPadLength {
+			return nil, fmt.Errorf("%s: padding too long (%d)", name, padLen)
+		}
+		padRunes := []rune(pad)
+		padding := make([]rune, 0, padLen)
+		for i := 0; i < padLen; i++ {
+			padding = append(padding, padRunes[i%len(padRunes)])
+		}
+		if atStart {
+			return string(padding) + s, nil
+		}
+		return s + string(padding), nil
+	}
+}
@@ -0,0 +1,86 @@
+package ext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestStringOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.NewEmpty()
+	jsonlogic.AddOpVar(jl)
+	AddStringOps(jl)
+	jsonlogic.TestCases{
+		// upper/lower.
+		{Logic: `{"upper":"abc"}`, Data: `null`, Result: "ABC"},
+		{Logic: `{"upper":"àé"}`, Data: `null`, Result: "ÀÉ"},
+		{Logic: `{"upper":1}`, Data: `null`, Result: "1"},
+		{Logic: `{"upper":{"var":"s"}}`, Data: `{"s":"x"}`, Result: "X"},
+		{Logic: `{"lower":"ABC"}`, Data: `null`, Result: "abc"},
+		{Logic: `{"lower":"ÀÉ"}`, Data: `null`, Result: "àé"},
+		{Logic: `{"upper":[]}`, Data: `null`, Err: true},
+		{Logic: `{"upper":[[]]}`, Data: `null`, Err: true},
+		{Logic: `{"lower":["a","b"]}`, Data: `null`, Err: true},
+		// trim.
+		{Logic: `{"trim":"  abc \n"}`, Data: `null`, Result: "abc"},
+		{Logic: `{"trim":["xxabcx","x"]}`, Data: `null`, Result: "abc"},
+		{Logic: `{"trim":["好abc好","好"]}`, Data: `null`, Result: "abc"},
+		{Logic: `{"trim":[{}]}`, Data: `null`, Err: true},
+		// split.
+		{Logic: `{"split":["a,b,c",","]}`, Data: `null`, Result: []interface{}{"a", "b", "c"}},
+		{Logic: `{"split":["a,b,c",",",2]}`, Data: `null`, Result: []interface{}{"a", "b,c"}},
+		{Logic: `{"split":["你好",""]}`, Data: `null`, Result: []interface{}{"你", "好"}},
+		{Logic: `{"split":["",","]}`, Data: `null`, Result: []interface{}{""}},
+		{Logic: `{"split":["a,b",",",0]}`, Data: `null`, Err: true},
+		{Logic: `{"split":["a,b",",",1.5]}`, Data: `null`, Err: true},
+		{Logic: `{"split":["a,b",",","x"]}`, Data: `null`, Err: true},
+		{Logic: `{"split":["a,b,c",",",1e300]}`, Data: `null`, Result: []interface{}{"a", "b", "c"}},
+		{Logic: `{"split":["a,b"]}`, Data: `null`, Err: true},
+		{Logic: `{"split":[["a"],","]}`, Data: `null`, Err: true},
+		// join.
+		{Logic: `{"join":[["a","b",1,true,null],"-"]}`, Data: `null`, Result: "a-b-1-true-null"},
+		{Logic: `{"join":[["a","b"]]}`, Data: `null`, Result: "ab"},
+		{Logic: `{"join":[{"var":"xs"},", "]}`, Data: `{"xs":["x","y"]}`, Result: "x, y"},
+		{Logic: `{"join":[[],","]}`, Data: `null`, Result: ""},
+		{Logic: `{"join":["ab",","]}`, Data: `null`, Err: true},
+		{Logic: `{"join":[[[1]],","]}`, Data: `null`, Err: true},
+		// replace.
+		{Logic: `{"replace":["a-b-c","-","+"]}`, Data: `null`, Result: "a+b+c"},
+		{Logic: `{"replace":["a-b-c","-","+",1]}`, Data: `null`, Result: "a+b-c"},
+		{Logic: `{"replace":["a-b-c","-","+",0]}`, Data: `null`, Result: "a-b-c"},
+		{Logic: `{"replace":["a-b-c","-","+",-1]}`, Data: `null`, Err: true},
+		{Logic: `{"replace":["a-b-c","-","+",0.5]}`, Data: `null`, Err: true},
+		{Logic: `{"replace":["a-b-c","-","+","x"]}`, Data: `null`, Err: true},
+		{Logic: `{"replace":["a-b-c","-","+",-1e300]}`, Data: `null`, Err: true},
+		{Logic: `{"replace":["a-b-c","-","+",1e300]}`, Data: `null`, Result: "a+b+c"},
+		{Logic: `{"replace":["a-b-c","-"]}`, Data: `null`, Err: true},
+		// starts_with/ends_with.
+		{Logic: `{"starts_with":["abc","ab"]}`, Data: `null`, Result: true},
+		{Logic: `{"starts_with":["abc","bc"]}`, Data: `null`, Result: false},
+		{Logic: `{"ends_with":["abc","bc"]}`, Data: `null`, Result: true},
+		{Logic: `{"ends_with":["abc",""]}`, Data: `null`, Result: true},
+		{Logic: `{"ends_with":[1.5,".5"]}`, Data: `null`, Result: true},
+		{Logic: `{"ends_with":["abc"]}`, Data: `null`, Err: true},
+		// length.
+		{Logic: `{"length":"你好"}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"length":""}`, Data: `null`, Result: float64(0)},
+		{Logic: `{"length":{"var":"s"}}`, Data: `{"s":"abc"}`, Result: float64(3)},
+		{Logic: `{"length":[{}]}`, Data: `null`, Err: true},
+		// pad_start/pad_end.
+		{Logic: `{"pad_start":["5",3,"0"]}`, Data: `null`, Result: "005"},
+		{Logic: `{"pad_start":["abc",6,"12"]}`, Data: `null`, Result: "121abc"},
+		{Logic: `{"pad_start":["abc",5]}`, Data: `null`, Result: "  abc"},
+		{Logic: `{"pad_start":["abc",2,"0"]}`, Data: `null`, Result: "abc"},
+		{Logic: `{"pad_start":["abc",5,""]}`, Data: `null`, Result: "abc"},
+		{Logic: `{"pad_start":["好",3,"你"]}`, Data: `null`, Result: "你你好"},
+		{Logic: `{"pad_end":["5",3,"0"]}`, Data: `null`, Result: "500"},
+		{Logic: `{"pad_end":["好",4,"ab"]}`, Data: `null`, Result: "好aba"},
+		{Logic: `{"pad_end":["a",1e10]}`, Data: `null`, Err: true},
+		{Logic: `{"pad_end":["a","x"]}`, Data: `null`, Err: true},
+		{Logic: `{"pad_end":["a",2.5]}`, Data: `null`, Err: true},
+		{Logic: `{"pad_end":["a"]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
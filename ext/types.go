@@ -0,0 +1,224 @@
+package ext
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+// maxJSONLength is the max length (in bytes) of string accepted by "parse_json".
+const maxJSONLength = 1 << 20
+
+// AddTypeOps adds all type inspection and conversion operations in this file to the JSONLogic instance:
+// "typeof", "is_number", "is_string", "is_array", "is_object", "is_null", "to_number", "to_string",
+// "to_bool", "parse_json" and "to_json".
+//
+// Conversions are the same as the implicit ones of other operations: "to_number" is jsonlogic.ToNumeric,
+// "to_string" is jsonlogic.ToString (so arrays/objects are errors, use "to_json" for them) and "to_bool"
+// is jsonlogic.ToBool (the truthiness used by "if"/"!!"/...).
+func AddTypeOps(jl *jsonlogic.JSONLogic) {
+	AddOpTypeof(jl)
+	AddOpIsNumber(jl)
+	AddOpIsString(jl)
+	AddOpIsArray(jl)
+	AddOpIsObject(jl)
+	AddOpIsNull(jl)
+	AddOpToNumber(jl)
+	AddOpToString(jl)
+	AddOpToBool(jl)
+	AddOpParseJSON(jl)
+	AddOpToJSON(jl)
+}
+
+// TypeOf returns the json type name of obj: "null", "boolean", "number", "string", "array" or "object".
+func TypeOf(obj interface{}) string {
+	switch obj.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		panic(fmt.Errorf("TypeOf got non-json type %T", obj))
+	}
+}
+
+// applyOne checks there is exactly one param and evaluates it.
+func applyOne(name string, apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	params, err := applyN(name, apply, params, data, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return params[0], nil
+}
+
+// AddOpTypeof adds "typeof" operation to the JSONLogic instance, which returns the json type name (see TypeOf).
+// NOTE: Unlike JavaScript's typeof, null and arrays have their own names:
+//   - {"typeof":{"var":"a"}} -> "array" if a is [1]
+//   - {"typeof":{"var":"missing"}} -> "null"
+func AddOpTypeof(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("typeof", opTypeof)
+}
+
+func opTypeof(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	r, err := applyOne("typeof", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return TypeOf(r), nil
+}
+
+func opIsType(name, typ string) jsonlogic.Operation {
+	return func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		r, err := applyOne(name, apply, params, data)
+		if err != nil {
+			return nil, err
+		}
+		return TypeOf(r) == typ, nil
+	}
+}
+
+// AddOpIsNumber adds "is_number" operation to the JSONLogic instance, numeric strings are not numbers:
+//   - {"is_number":1} -> true
+//   - {"is_number":"1"} -> false
+func AddOpIsNumber(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("is_number", opIsType("is_number", "number"))
+}
+
+// AddOpIsString adds "is_string" operation to the JSONLogic instance:
+//   - {"is_string":"1"} -> true
+func AddOpIsString(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("is_string", opIsType("is_string", "string"))
+}
+
+// AddOpIsArray adds "is_array" operation to the JSONLogic instance:
+//   - {"is_array":[[1]]} -> true
+func AddOpIsArray(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("is_array", opIsType("is_array", "array"))
+}
+
+// AddOpIsObject adds "is_object" operation to the JSONLogic instance, null and arrays are not objects:
+//   - {"is_object":{"var":""}} -> true if data is {}
+func AddOpIsObject(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("is_object", opIsType("is_object", "object"))
+}
+
+// AddOpIsNull adds "is_null" operation to the JSONLogic instance, missing values are null:
+//   - {"is_null":{"var":"missing"}} -> true
+func AddOpIsNull(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("is_null", opIsType("is_null", "null"))
+}
+
+// AddOpToNumber adds "to_number" operation to the JSONLogic instance, which converts a value by
+// jsonlogic.ToNumeric:
+//   - {"to_number":"1.5"} -> 1.5
+//   - {"to_number":null} -> 0
+//   - {"to_number":"abc"} -> error
+func AddOpToNumber(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("to_number", opToNumber)
+}
+
+func opToNumber(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	r, err := applyOne("to_number", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	n, err := jsonlogic.ToNumeric(r)
+	if err != nil {
+		return nil, fmt.Errorf("to_number: %s", err.Error())
+	}
+	return n, nil
+}
+
+// AddOpToString adds "to_string" operation to the JSONLogic instance, which converts a value by
+// jsonlogic.ToString:
+//   - {"to_string":1.5} -> "1.5"
+//   - {"to_string":null} -> "null"
+func AddOpToString(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("to_string", opToString)
+}
+
+func opToString(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	r, err := applyOne("to_string", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	s, err := jsonlogic.ToString(r)
+	if err != nil {
+		return nil, fmt.Errorf("to_string: %s", err.Error())
+	}
+	return s, nil
+}
+
+// AddOpToBool adds "to_bool" operation to the JSONLogic instance, which converts a value by jsonlogic.ToBool,
+// the same as "!!":
+//   - {"to_bool":[[]]} -> false
+//   - {"to_bool":"0"} -> true
+func AddOpToBool(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("to_bool", opToBool)
+}
+
+func opToBool(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	r, err := applyOne("to_bool", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return jsonlogic.ToBool(r), nil
+}
+
+// AddOpParseJSON adds "parse_json" operation to the JSONLogic instance, which parses a json string (at most
+// 1MiB). The result is data, not logic, it is never evaluated:
+//   - {"parse_json":"{\"a\":[1,2]}"} -> {"a":[1,2]}
+//   - {"parse_json":"{"} -> error
+func AddOpParseJSON(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("parse_json", opParseJSON)
+}
+
+func opParseJSON(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	r, err := applyOne("parse_json", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := r.(string)
+	if !ok {
+		return nil, fmt.Errorf("parse_json: expect string but got %T", r)
+	}
+	if len(s) > maxJSONLength {
+		return nil, fmt.Errorf("parse_json: json too long (%d > %d)", len(s), maxJSONLength)
+	}
+	var ret interface{}
+	if err := json.Unmarshal([]byte(s), &ret); err != nil {
+		return nil, fmt.Errorf("parse_json: %s", err.Error())
+	}
+	return ret, nil
+}
+
+// AddOpToJSON adds "to_json" operation to the JSONLogic instance, which serializes a value to compact json,
+// keys of objects are sorted and "<"/">"/"&" are not escaped:
+//   - {"to_json":{"var":""}} -> "{\"a\":1,\"b\":[true,null]}" if data is {"b":[true,null],"a":1}
+func AddOpToJSON(jl *jsonlogic.JSONLogic) {
+	jl.AddOperation("to_json", opToJSON)
+}
+
+func opToJSON(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+	r, err := applyOne("to_json", apply, params, data)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(r); err != nil {
+		return nil, fmt.Errorf("to_json: %s", err.Error())
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
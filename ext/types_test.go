@@ -0,0 +1,61 @@
+package ext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+func TestTypeOps(t *testing.T) {
+	assert := assert.New(t)
+	jl := jsonlogic.New()
+	AddTypeOps(jl)
+	jsonlogic.TestCases{
+		// typeof.
+		{Logic: `{"typeof":{"var":"a"}}`, Data: `{"a":[1]}`, Result: "array"},
+		{Logic: `{"typeof":{"var":""}}`, Data: `{"a":[1]}`, Result: "object"},
+		{Logic: `{"typeof":{"var":"missing"}}`, Data: `{}`, Result: "null"},
+		{Logic: `{"typeof":1}`, Data: `null`, Result: "number"},
+		{Logic: `{"typeof":"1"}`, Data: `null`, Result: "string"},
+		{Logic: `{"typeof":false}`, Data: `null`, Result: "boolean"},
+		{Logic: `{"typeof":[1,2]}`, Data: `null`, Err: true},
+		// is_*.
+		{Logic: `{"is_number":1}`, Data: `null`, Result: true},
+		{Logic: `{"is_number":"1"}`, Data: `null`, Result: false},
+		{Logic: `{"is_string":"1"}`, Data: `null`, Result: true},
+		{Logic: `{"is_string":null}`, Data: `null`, Result: false},
+		{Logic: `{"is_array":[[1]]}`, Data: `null`, Result: true},
+		{Logic: `{"is_array":{"var":"a"}}`, Data: `{"a":{}}`, Result: false},
+		{Logic: `{"is_object":{"var":"a"}}`, Data: `{"a":{}}`, Result: true},
+		{Logic: `{"is_object":{"var":"a"}}`, Data: `{"a":null}`, Result: false},
+		{Logic: `{"is_null":{"var":"missing"}}`, Data: `{}`, Result: true},
+		{Logic: `{"is_null":0}`, Data: `null`, Result: false},
+		// to_number/to_string/to_bool.
+		{Logic: `{"to_number":"1.5"}`, Data: `null`, Result: float64(1.5)},
+		{Logic: `{"to_number":null}`, Data: `null`, Result: float64(0)},
+		{Logic: `{"to_number":true}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"to_number":"abc"}`, Data: `null`, Err: true},
+		{Logic: `{"to_number":"Infinity"}`, Data: `null`, Err: true},
+		{Logic: `{"to_number":[[1]]}`, Data: `null`, Err: true},
+		{Logic: `{"to_string":1.5}`, Data: `null`, Result: "1.5"},
+		{Logic: `{"to_string":null}`, Data: `null`, Result: "null"},
+		{Logic: `{"to_string":1e21}`, Data: `null`, Result: "1000000000000000000000"},
+		{Logic: `{"to_string":[[1]]}`, Data: `null`, Err: true},
+		{Logic: `{"to_bool":[[]]}`, Data: `null`, Result: false},
+		{Logic: `{"to_bool":"0"}`, Data: `null`, Result: true},
+		{Logic: `{"to_bool":0}`, Data: `null`, Result: false},
+		{Logic: `{"to_bool":{"var":""}}`, Data: `{"a":1}`, Result: true},
+		// parse_json/to_json.
+		{Logic: `{"parse_json":"{\"a\":[1,2]}"}`, Data: `null`, Result: map[string]interface{}{"a": []interface{}{float64(1), float64(2)}}},
+		{Logic: `{"parse_json":"{\"var\":\"x\"}"}`, Data: `{"x":1}`, Result: map[string]interface{}{"var": "x"}},
+		{Logic: `{"parse_json":{"var":"s"}}`, Data: `{"s":"null"}`, Result: nil},
+		{Logic: `{"parse_json":"{"}`, Data: `null`, Err: true},
+		{Logic: `{"parse_json":"1 2"}`, Data: `null`, Err: true},
+		{Logic: `{"parse_json":1}`, Data: `null`, Err: true},
+		{Logic: `{"to_json":{"var":""}}`, Data: `{"b":[true,null],"a":1}`, Result: `{"a":1,"b":[true,null]}`},
+		{Logic: `{"to_json":"<a&b>"}`, Data: `null`, Result: `"<a&b>"`},
+		{Logic: `{"to_json":{"parse_json":"[1.5,\"x\"]"}}`, Data: `null`, Result: `[1.5,"x"]`},
+	}.Run(assert, jl)
+}
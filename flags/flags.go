@@ -0,0 +1,402 @@
+// Package flags is a feature flag evaluation engine built on json logic.
+//
+// A flag has a set of named variants (arbitrary json values), and decides which variant to serve for an
+// evaluation context (the data of json logic) by, in order:
+//   - The off variant if the flag is not enabled.
+//   - The off variant if any prerequisite flag does not serve one of the required variants.
+//   - The first targeting rule whose "if" logic is truthy.
+//   - The default.
+//
+// Rules and the default serve either a fixed variant or a percentage split, which puts the context into
+// a bucket deterministically by hashing the flag's salt and a context attribute (see ext.Bucket), so the same
+// user always gets the same variant as long as the split is unchanged.
+//
+// An example flag definition in YAML (see ParseYAML):
+//   flags:
+//     new-checkout:
+//       enabled: true
+//       variants: {"on": true, "off": false}
+//       off_variant: "off"
+//       prerequisites: [{flag: payments-v2, variants: ["on"]}]
+//       rules:
+//         - if: {"in": [{"var": "country"}, ["US", "CA"]]}
+//           variant: "on"
+//         - if: {"===": [{"var": "plan"}, "pro"]}
+//           split: [{variant: "on", weight: 25}, {variant: "off", weight: 75}]
+//       default: {variant: "off"}
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/huangjunwen/jsonlogic-go"
+	"github.com/huangjunwen/jsonlogic-go/ext"
+)
+
+// DefaultBucketBy is the default context attribute used by percentage splits.
+const DefaultBucketBy = "key"
+
+// bucketPrecision is the number of decimal digits of buckets, so weights of splits can be as fine as 0.0001%.
+const bucketPrecision = 4
+
+// ErrFlagNotFound is returned (wrapped) in Result.Err when evaluating an unknown flag.
+var ErrFlagNotFound = errors.New("flag not found")
+
+// Flag is the definition of a feature flag.
+type Flag struct {
+	// Description is an optional human readable description.
+	Description string `json:"description,omitempty"`
+
+	// Enabled is false to serve OffVariant for everyone.
+	Enabled bool `json:"enabled"`
+
+	// Variants are the values the flag can serve, by names.
+	Variants map[string]interface{} `json:"variants"`
+
+	// OffVariant is served when the flag is not enabled, a prerequisite fails or an error occurs.
+	OffVariant string `json:"off_variant"`
+
+	// Prerequisites are checked in order before rules.
+	Prerequisites []Prerequisite `json:"prerequisites,omitempty"`
+
+	// Rules are targeting rules evaluated in order, the first matching one is served.
+	Rules []Rule `json:"rules,omitempty"`
+
+	// Default is served if no rule matches.
+	Default Serve `json:"default"`
+
+	// BucketBy is the path (in the syntax of "var") of the context attribute used by percentage splits,
+	// DefaultBucketBy if empty.
+	BucketBy string `json:"bucket_by,omitempty"`
+
+	// Salt is the seed of hashing in percentage splits, the flag key if empty. Change it to reshuffle buckets.
+	Salt string `json:"salt,omitempty"`
+}
+
+// Prerequisite requires another flag to serve one of the variants.
+type Prerequisite struct {
+	Flag     string   `json:"flag"`
+	Variants []string `json:"variants"`
+}
+
+// Rule is a targeting rule: serves a variant or a split if the "if" logic is truthy (by jsonlogic.ToBool).
+type Rule struct {
+	If interface{} `json:"if"`
+	Serve
+}
+
+// Serve is what to serve: exactly one of a fixed variant or a percentage split.
+type Serve struct {
+	Variant string  `json:"variant,omitempty"`
+	Split   []Split `json:"split,omitempty"`
+}
+
+// Split is a variant with its weight in a percentage split. Weights of a split sum to 100.
+type Split struct {
+	Variant string  `json:"variant"`
+	Weight  float64 `json:"weight"`
+}
+
+// Reason is why a variant is served.
+type Reason string
+
+const (
+	// ReasonOff means the flag is not enabled.
+	ReasonOff Reason = "OFF"
+	// ReasonPrerequisiteFailed means a prerequisite flag does not serve a required variant.
+	ReasonPrerequisiteFailed Reason = "PREREQUISITE_FAILED"
+	// ReasonRuleMatch means a targeting rule matches.
+	ReasonRuleMatch Reason = "RULE_MATCH"
+	// ReasonFallthrough means no rule matches and the default is served.
+	ReasonFallthrough Reason = "FALLTHROUGH"
+	// ReasonError means an error occurred, see Result.Err.
+	ReasonError Reason = "ERROR"
+)
+
+// Result is the result of evaluating a flag.
+type Result struct {
+	// FlagKey is the key of the evaluated flag.
+	FlagKey string
+
+	// Variant is the name of the served variant, and Value is its value. They are empty/nil if
+	// the flag is not found.
+	Variant string
+	Value   interface{}
+
+	// Reason is why the variant is served.
+	Reason Reason
+
+	// RuleIndex is the index of the matched rule for ReasonRuleMatch, -1 otherwise.
+	RuleIndex int
+
+	// PrerequisiteKey is the key of the failed prerequisite flag for ReasonPrerequisiteFailed.
+	PrerequisiteKey string
+
+	// Err is the error for ReasonError.
+	Err error
+}
+
+// Evaluator evaluates a set of flags. It is safe for concurrent use.
+type Evaluator struct {
+	jl    *jsonlogic.JSONLogic
+	flags map[string]*Flag
+}
+
+// New validates flags (by keys) and creates an Evaluator evaluating rules by jl. If jl is nil,
+// jsonlogic.DefaultJSONLogic is used. Flags must not be modified after that.
+func New(jl *jsonlogic.JSONLogic, flags map[string]*Flag) (*Evaluator, error) {
+	if jl == nil {
+		jl = jsonlogic.DefaultJSONLogic
+	}
+	e := &Evaluator{
+		jl:    jl,
+		flags: make(map[string]*Flag, len(flags)),
+	}
+	for key, flag := range flags {
+		if err := validateFlag(key, flag, flags); err != nil {
+			return nil, err
+		}
+		e.flags[key] = flag
+	}
+	states := map[string]int{}
+	for key := range flags {
+		if err := checkCycle(key, flags, states); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func validateFlag(key string, flag *Flag, flags map[string]*Flag) error {
+	if key == "" {
+		return fmt.Errorf("flags: empty flag key")
+	}
+	if flag == nil {
+		return fmt.Errorf("flags: flag %q: nil definition", key)
+	}
+	if len(flag.Variants) == 0 {
+		return fmt.Errorf("flags: flag %q: no variant", key)
+	}
+	if _, ok := flag.Variants[flag.OffVariant]; !ok {
+		return fmt.Errorf("flags: flag %q: unknown off variant %q", key, flag.OffVariant)
+	}
+	for i, prereq := range flag.Prerequisites {
+		other, ok := flags[prereq.Flag]
+		if !ok || other == nil {
+			return fmt.Errorf("flags: flag %q: prerequisite %d: unknown flag %q", key, i, prereq.Flag)
+		}
+		if len(prereq.Variants) == 0 {
+			return fmt.Errorf("flags: flag %q: prerequisite %d: no variant", key, i)
+		}
+		for _, v := range prereq.Variants {
+			if _, ok := other.Variants[v]; !ok {
+				return fmt.Errorf("flags: flag %q: prerequisite %d: unknown variant %q of flag %q", key, i, v, prereq.Flag)
+			}
+		}
+	}
+	for i, rule := range flag.Rules {
+		if err := validateServe(flag, rule.Serve); err != nil {
+			return fmt.Errorf("flags: flag %q: rule %d: %s", key, i, err.Error())
+		}
+	}
+	if err := validateServe(flag, flag.Default); err != nil {
+		return fmt.Errorf("flags: flag %q: default: %s", key, err.Error())
+	}
+	return nil
+}
+
+func validateServe(flag *Flag, serve Serve) error {
+	if (serve.Variant == "") == (len(serve.Split) == 0) {
+		return fmt.Errorf("expect exactly one of variant or split")
+	}
+	if serve.Variant != "" {
+		if _, ok := flag.Variants[serve.Variant]; !ok {
+			return fmt.Errorf("unknown variant %q", serve.Variant)
+		}
+		return nil
+	}
+	sum := 0.0
+	for _, split := range serve.Split {
+		if _, ok := flag.Variants[split.Variant]; !ok {
+			return fmt.Errorf("unknown variant %q", split.Variant)
+		}
+		if !(split.Weight >= 0) || math.IsInf(split.Weight, 0) {
+			return fmt.Errorf("invalid weight %v of variant %q", split.Weight, split.Variant)
+		}
+		sum += split.Weight
+	}
+	if math.Abs(sum-100) > 1e-9 {
+		return fmt.Errorf("weights sum to %v instead of 100", sum)
+	}
+	return nil
+}
+
+// States of flags in checkCycle.
+const (
+	stateUnvisited = iota
+	stateVisiting
+	stateVisited
+)
+
+// checkCycle returns an error if there is a cycle of prerequisites from key. states is shared by calls
+// so that each flag is visited once.
+func checkCycle(key string, flags map[string]*Flag, states map[string]int) error {
+	switch states[key] {
+	case stateVisiting:
+		return fmt.Errorf("flags: flag %q: cyclic prerequisites", key)
+	case stateVisited:
+		return nil
+	}
+	states[key] = stateVisiting
+	for _, prereq := range flags[key].Prerequisites {
+		if err := checkCycle(prereq.Flag, flags, states); err != nil {
+			return err
+		}
+	}
+	states[key] = stateVisited
+	return nil
+}
+
+// Len returns the number of flags.
+func (e *Evaluator) Len() int {
+	return len(e.flags)
+}
+
+// Flag returns the definition of a flag, or nil if not found.
+func (e *Evaluator) Flag(key string) *Flag {
+	return e.flags[key]
+}
+
+// Evaluate evaluates a flag against the context. Errors (unknown flag, errors of rule logic or missing
+// bucket attributes) are reported in the result with ReasonError, in which case the off variant is served
+// if the flag exists.
+func (e *Evaluator) Evaluate(flagKey string, context interface{}) Result {
+	flag, ok := e.flags[flagKey]
+	if !ok {
+		return Result{
+			FlagKey:   flagKey,
+			Reason:    ReasonError,
+			RuleIndex: -1,
+			Err:       fmt.Errorf("flags: %w: %q", ErrFlagNotFound, flagKey),
+		}
+	}
+	return e.evaluate(flagKey, flag, context, map[string]Result{})
+}
+
+// evaluate evaluates a flag, results of prerequisite flags are cached in evaluated (by keys) so that
+// each flag is evaluated at most once in an Evaluate call.
+func (e *Evaluator) evaluate(key string, flag *Flag, context interface{}, evaluated map[string]Result) Result {
+	if !flag.Enabled {
+		return e.result(key, flag, flag.OffVariant, ReasonOff, -1)
+	}
+
+	for _, prereq := range flag.Prerequisites {
+		r, ok := evaluated[prereq.Flag]
+		if !ok {
+			r = e.evaluate(prereq.Flag, e.flags[prereq.Flag], context, evaluated)
+			evaluated[prereq.Flag] = r
+		}
+		if r.Reason == ReasonError {
+			return e.errorResult(key, flag, fmt.Errorf("prerequisite %q: %w", prereq.Flag, r.Err))
+		}
+		if !contains(prereq.Variants, r.Variant) {
+			ret := e.result(key, flag, flag.OffVariant, ReasonPrerequisiteFailed, -1)
+			ret.PrerequisiteKey = prereq.Flag
+			return ret
+		}
+	}
+
+	for i, rule := range flag.Rules {
+		r, err := e.jl.Apply(rule.If, context)
+		if err != nil {
+			return e.errorResult(key, flag, fmt.Errorf("rule %d: %w", i, err))
+		}
+		if !jsonlogic.ToBool(r) {
+			continue
+		}
+		variant, err := e.serve(key, flag, rule.Serve, context)
+		if err != nil {
+			return e.errorResult(key, flag, fmt.Errorf("rule %d: %w", i, err))
+		}
+		return e.result(key, flag, variant, ReasonRuleMatch, i)
+	}
+
+	variant, err := e.serve(key, flag, flag.Default, context)
+	if err != nil {
+		return e.errorResult(key, flag, fmt.Errorf("default: %w", err))
+	}
+	return e.result(key, flag, variant, ReasonFallthrough, -1)
+}
+
+// serve returns the variant to serve.
+func (e *Evaluator) serve(key string, flag *Flag, serve Serve, context interface{}) (string, error) {
+	if serve.Variant != "" {
+		return serve.Variant, nil
+	}
+
+	bucketBy := flag.BucketBy
+	if bucketBy == "" {
+		bucketBy = DefaultBucketBy
+	}
+	attr, err := e.jl.Apply(map[string]interface{}{"var": bucketBy}, context)
+	if err != nil {
+		return "", err
+	}
+	var id string
+	switch attr.(type) {
+	case string, float64:
+		id, err = jsonlogic.ToString(attr)
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("expect string or number as bucket attribute %q but got %T", bucketBy, attr)
+	}
+	salt := flag.Salt
+	if salt == "" {
+		salt = key
+	}
+
+	bucket := ext.Bucket(salt, id, bucketPrecision)
+	cum := 0.0
+	last := ""
+	for _, split := range serve.Split {
+		if split.Weight == 0 {
+			continue
+		}
+		cum += split.Weight
+		if bucket < cum {
+			return split.Variant, nil
+		}
+		last = split.Variant
+	}
+	// Rounding errors of weights.
+	return last, nil
+}
+
+func (e *Evaluator) result(key string, flag *Flag, variant string, reason Reason, ruleIndex int) Result {
+	return Result{
+		FlagKey:   key,
+		Variant:   variant,
+		Value:     flag.Variants[variant],
+		Reason:    reason,
+		RuleIndex: ruleIndex,
+	}
+}
+
+func (e *Evaluator) errorResult(key string, flag *Flag, err error) Result {
+	ret := e.result(key, flag, flag.OffVariant, ReasonError, -1)
+	ret.Err = fmt.Errorf("flags: flag %q: %w", key, err)
+	return ret
+}
+
+func contains(ss []string, s string) bool {
+	for _, item := range ss {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,281 @@
+package flags
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+)
+
+const testFlags = `{"flags":{
+  "payments-v2":{
+    "enabled":true,
+    "variants":{"on":true,"off":false},
+    "off_variant":"off",
+    "rules":[{"if":{"in":[{"var":"country"},["US","CA"]]},"variant":"on"}],
+    "default":{"variant":"off"}
+  },
+  "new-checkout":{
+    "enabled":true,
+    "variants":{"on":true,"off":false},
+    "off_variant":"off",
+    "prerequisites":[{"flag":"payments-v2","variants":["on"]}],
+    "rules":[
+      {"if":{"===":[{"var":"plan"},"beta"]},"variant":"on"},
+      {"if":{"===":[{"var":"plan"},"pro"]},"split":[{"variant":"on","weight":50},{"variant":"off","weight":50}]}
+    ],
+    "default":{"variant":"off"}
+  },
+  "disabled":{
+    "enabled":false,
+    "variants":{"on":true,"off":false},
+    "off_variant":"off",
+    "rules":[{"if":true,"variant":"on"}],
+    "default":{"variant":"on"}
+  },
+  "rollout":{
+    "enabled":true,
+    "variants":{"on":true,"off":false},
+    "off_variant":"off",
+    "bucket_by":"user.id",
+    "default":{"split":[{"variant":"on","weight":30},{"variant":"off","weight":70}]}
+  },
+  "rollout-salted":{
+    "enabled":true,
+    "variants":{"on":true,"off":false},
+    "off_variant":"off",
+    "bucket_by":"user.id",
+    "salt":"reshuffled",
+    "default":{"split":[{"variant":"on","weight":30},{"variant":"off","weight":70}]}
+  },
+  "theme":{
+    "enabled":true,
+    "variants":{"light":{"bg":"#fff"},"dark":{"bg":"#000"}},
+    "off_variant":"light",
+    "rules":[{"if":{"var":"dark_mode"},"variant":"dark"}],
+    "default":{"variant":"light"}
+  },
+  "broken":{
+    "enabled":true,
+    "variants":{"on":true,"off":false},
+    "off_variant":"off",
+    "rules":[{"if":{"unknown_op":1},"variant":"on"}],
+    "default":{"variant":"on"}
+  },
+  "needs-broken":{
+    "enabled":true,
+    "variants":{"on":true,"off":false},
+    "off_variant":"off",
+    "prerequisites":[{"flag":"broken","variants":["on"]}],
+    "default":{"variant":"on"}
+  }
+}}`
+
+func TestEvaluate(t *testing.T) {
+	assert := assert.New(t)
+	defs, err := ParseJSON([]byte(testFlags))
+	assert.NoError(err)
+	e, err := New(jsonlogic.New(), defs)
+	assert.NoError(err)
+	assert.Equal(8, e.Len())
+
+	for _, tc := range []struct {
+		Flag            string
+		Context         string
+		Variant         string
+		Value           interface{}
+		Reason          Reason
+		RuleIndex       int
+		PrerequisiteKey string
+	}{
+		{"payments-v2", `{"country":"CA"}`, "on", true, ReasonRuleMatch, 0, ""},
+		{"payments-v2", `{"country":"FR"}`, "off", false, ReasonFallthrough, -1, ""},
+		{"payments-v2", `null`, "off", false, ReasonFallthrough, -1, ""},
+		{"new-checkout", `{"country":"US","plan":"beta"}`, "on", true, ReasonRuleMatch, 0, ""},
+		{"new-checkout", `{"country":"US","plan":"pro","key":"u2"}`, "on", true, ReasonRuleMatch, 1, ""},
+		{"new-checkout", `{"country":"US","plan":"pro","key":"u1"}`, "off", false, ReasonRuleMatch, 1, ""},
+		{"new-checkout", `{"country":"US","plan":"free"}`, "off", false, ReasonFallthrough, -1, ""},
+		{"new-checkout", `{"country":"FR","plan":"beta"}`, "off", false, ReasonPrerequisiteFailed, -1, "payments-v2"},
+		{"disabled", `{}`, "off", false, ReasonOff, -1, ""},
+		{"rollout", `{"user":{"id":"1"}}`, "on", true, ReasonFallthrough, -1, ""},
+		{"rollout", `{"user":{"id":1}}`, "on", true, ReasonFallthrough, -1, ""},
+		{"rollout", `{"user":{"id":"2"}}`, "off", false, ReasonFallthrough, -1, ""},
+		{"rollout-salted", `{"user":{"id":"1"}}`, "off", false, ReasonFallthrough, -1, ""},
+		{"theme", `{"dark_mode":true}`, "dark", map[string]interface{}{"bg": "#000"}, ReasonRuleMatch, 0, ""},
+		{"theme", `{"dark_mode":0}`, "light", map[string]interface{}{"bg": "#fff"}, ReasonFallthrough, -1, ""},
+	} {
+		var ctx interface{}
+		assert.NoError(json.Unmarshal([]byte(tc.Context), &ctx))
+		r := e.Evaluate(tc.Flag, ctx)
+		assert.Equal(Result{
+			FlagKey:         tc.Flag,
+			Variant:         tc.Variant,
+			Value:           tc.Value,
+			Reason:          tc.Reason,
+			RuleIndex:       tc.RuleIndex,
+			PrerequisiteKey: tc.PrerequisiteKey,
+		}, r, "flag=%s context=%s", tc.Flag, tc.Context)
+	}
+
+	// Errors.
+	for _, tc := range []struct {
+		Flag    string
+		Context string
+		Variant string
+	}{
+		{"unknown", `{}`, ""},
+		{"broken", `{}`, "off"},
+		{"needs-broken", `{}`, "off"},
+		// Missing bucket attribute.
+		{"rollout", `{}`, "off"},
+		{"rollout", `{"user":{"id":[1]}}`, "off"},
+	} {
+		var ctx interface{}
+		assert.NoError(json.Unmarshal([]byte(tc.Context), &ctx))
+		r := e.Evaluate(tc.Flag, ctx)
+		assert.Equal(ReasonError, r.Reason, "flag=%s context=%s", tc.Flag, tc.Context)
+		assert.Equal(tc.Variant, r.Variant, "flag=%s context=%s", tc.Flag, tc.Context)
+		assert.Equal(-1, r.RuleIndex, "flag=%s context=%s", tc.Flag, tc.Context)
+		assert.Error(r.Err, "flag=%s context=%s", tc.Flag, tc.Context)
+	}
+	assert.True(errors.Is(e.Evaluate("unknown", nil).Err, ErrFlagNotFound))
+}
+
+func TestSplitDistribution(t *testing.T) {
+	assert := assert.New(t)
+	e, err := New(nil, map[string]*Flag{
+		"f": {
+			Enabled:    true,
+			Variants:   map[string]interface{}{"a": "a", "b": "b", "c": "c", "d": "d"},
+			OffVariant: "a",
+			Default: Serve{Split: []Split{
+				{Variant: "a", Weight: 10},
+				{Variant: "b", Weight: 0},
+				{Variant: "c", Weight: 20.5},
+				{Variant: "d", Weight: 69.5},
+			}},
+		},
+	})
+	assert.NoError(err)
+
+	const n = 100000
+	counts := map[string]int{}
+	for i := 0; i < n; i++ {
+		r := e.Evaluate("f", map[string]interface{}{"key": float64(i)})
+		assert.NoError(r.Err)
+		counts[r.Variant]++
+	}
+	assert.InDelta(10000, counts["a"], 1000)
+	assert.Equal(0, counts["b"])
+	assert.InDelta(20500, counts["c"], 1000)
+	assert.InDelta(69500, counts["d"], 1000)
+}
+
+func TestPrerequisiteDiamond(t *testing.T) {
+	assert := assert.New(t)
+	// Counts evaluations of leaf rules.
+	count := 0
+	jl := jsonlogic.NewInherit(jsonlogic.DefaultJSONLogic)
+	jl.AddOperation("count", func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		count++
+		return true, nil
+	})
+
+	// Flags of each level require both flags of the previous level.
+	const depth = 30
+	flag := func(prereqs ...string) *Flag {
+		f := &Flag{
+			Enabled:    true,
+			Variants:   map[string]interface{}{"on": true, "off": false},
+			OffVariant: "off",
+			Default:    Serve{Variant: "on"},
+		}
+		for _, key := range prereqs {
+			f.Prerequisites = append(f.Prerequisites, Prerequisite{Flag: key, Variants: []string{"on"}})
+		}
+		return f
+	}
+	defs := map[string]*Flag{}
+	for _, key := range []string{"a0", "b0"} {
+		defs[key] = flag()
+		defs[key].Rules = []Rule{{If: map[string]interface{}{"count": []interface{}{}}, Serve: Serve{Variant: "on"}}}
+	}
+	for i := 1; i <= depth; i++ {
+		prev := []string{fmt.Sprintf("a%d", i-1), fmt.Sprintf("b%d", i-1)}
+		defs[fmt.Sprintf("a%d", i)] = flag(prev...)
+		defs[fmt.Sprintf("b%d", i)] = flag(prev...)
+	}
+	e, err := New(jl, defs)
+	assert.NoError(err)
+
+	r := e.Evaluate(fmt.Sprintf("a%d", depth), nil)
+	assert.NoError(r.Err)
+	assert.Equal(ReasonFallthrough, r.Reason)
+	assert.Equal(2, count)
+}
+
+func TestNewInvalid(t *testing.T) {
+	assert := assert.New(t)
+	valid := func() *Flag {
+		return &Flag{
+			Enabled:    true,
+			Variants:   map[string]interface{}{"on": true, "off": false},
+			OffVariant: "off",
+			Default:    Serve{Variant: "off"},
+		}
+	}
+
+	for _, tc := range []struct {
+		Name   string
+		Modify func(flags map[string]*Flag)
+	}{
+		{"empty key", func(flags map[string]*Flag) { flags[""] = valid() }},
+		{"nil flag", func(flags map[string]*Flag) { flags["a"] = nil }},
+		{"no variant", func(flags map[string]*Flag) { flags["a"].Variants = nil }},
+		{"unknown off variant", func(flags map[string]*Flag) { flags["a"].OffVariant = "x" }},
+		{"no default", func(flags map[string]*Flag) { flags["a"].Default = Serve{} }},
+		{"unknown default variant", func(flags map[string]*Flag) { flags["a"].Default.Variant = "x" }},
+		{"both variant and split", func(flags map[string]*Flag) {
+			flags["a"].Default.Split = []Split{{Variant: "on", Weight: 100}}
+		}},
+		{"unknown rule variant", func(flags map[string]*Flag) {
+			flags["a"].Rules = []Rule{{If: true, Serve: Serve{Variant: "x"}}}
+		}},
+		{"unknown split variant", func(flags map[string]*Flag) {
+			flags["a"].Rules = []Rule{{If: true, Serve: Serve{Split: []Split{{Variant: "x", Weight: 100}}}}}
+		}},
+		{"weights not 100", func(flags map[string]*Flag) {
+			flags["a"].Default = Serve{Split: []Split{{Variant: "on", Weight: 50}, {Variant: "off", Weight: 40}}}
+		}},
+		{"negative weight", func(flags map[string]*Flag) {
+			flags["a"].Default = Serve{Split: []Split{{Variant: "on", Weight: 110}, {Variant: "off", Weight: -10}}}
+		}},
+		{"unknown prerequisite flag", func(flags map[string]*Flag) {
+			flags["a"].Prerequisites = []Prerequisite{{Flag: "x", Variants: []string{"on"}}}
+		}},
+		{"unknown prerequisite variant", func(flags map[string]*Flag) {
+			flags["a"].Prerequisites = []Prerequisite{{Flag: "b", Variants: []string{"x"}}}
+		}},
+		{"no prerequisite variant", func(flags map[string]*Flag) {
+			flags["a"].Prerequisites = []Prerequisite{{Flag: "b"}}
+		}},
+		{"cyclic prerequisites", func(flags map[string]*Flag) {
+			flags["a"].Prerequisites = []Prerequisite{{Flag: "b", Variants: []string{"on"}}}
+			flags["b"].Prerequisites = []Prerequisite{{Flag: "a", Variants: []string{"on"}}}
+		}},
+		{"self prerequisite", func(flags map[string]*Flag) {
+			flags["a"].Prerequisites = []Prerequisite{{Flag: "a", Variants: []string{"on"}}}
+		}},
+	} {
+		flags := map[string]*Flag{"a": valid(), "b": valid()}
+		_, err := New(nil, flags)
+		assert.NoError(err, tc.Name)
+		tc.Modify(flags)
+		_, err = New(nil, flags)
+		assert.Error(err, tc.Name)
+	}
+}
@@ -0,0 +1,62 @@
+package flags
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/huangjunwen/jsonlogic-go/internal/yamljson"
+)
+
+// config is the format of flag definition files: {"flags":{key:flag,...}}.
+type config struct {
+	Flags map[string]*Flag `json:"flags"`
+}
+
+// ParseJSON parses flag definitions in json. Unknown fields are errors to catch typos.
+// The result should be validated by New.
+func ParseJSON(b []byte) (map[string]*Flag, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var c config
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("flags: %s", err.Error())
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("flags: unexpected data after flag definitions")
+	}
+	if c.Flags == nil {
+		return nil, fmt.Errorf("flags: missing \"flags\"")
+	}
+	return c.Flags, nil
+}
+
+// ParseYAML parses flag definitions in YAML, with the same structure as json (see the package doc
+// for an example). The result should be validated by New.
+func ParseYAML(b []byte) (map[string]*Flag, error) {
+	j, err := yamljson.ToJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("flags: %s", err.Error())
+	}
+	return ParseJSON(j)
+}
+
+// LoadFile reads flag definitions from a json (".json") or YAML (".yaml"/".yml") file.
+// The result should be validated by New.
+func LoadFile(path string) (map[string]*Flag, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("flags: %s", err.Error())
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return ParseJSON(b)
+	case ".yaml", ".yml":
+		return ParseYAML(b)
+	default:
+		return nil, fmt.Errorf("flags: unknown file extension %q", ext)
+	}
+}
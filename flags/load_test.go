@@ -0,0 +1,132 @@
+package flags
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testYAML = `
+flags:
+  new-checkout:
+    description: New checkout flow
+    enabled: true
+    variants: {"on": true, "off": false}
+    off_variant: "off"
+    rules:
+      - if: {"in": [{"var": "country"}, ["US", "CA"]]}
+        variant: "on"
+      - if: {"===": [{"var": "plan"}, "pro"]}
+        split: [{variant: "on", weight: 25}, {variant: "off", weight: 75}]
+    default: {variant: "off"}
+  limits:
+    enabled: true
+    variants:
+      small: {max_items: 10, tags: [a, b]}
+      large: {max_items: 100}
+    off_variant: small
+    default:
+      variant: large
+`
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+
+	flags, err := ParseYAML([]byte(testYAML))
+	assert.NoError(err)
+	assert.Equal(map[string]*Flag{
+		"new-checkout": {
+			Description: "New checkout flow",
+			Enabled:     true,
+			Variants:    map[string]interface{}{"on": true, "off": false},
+			OffVariant:  "off",
+			Rules: []Rule{
+				{
+					If: map[string]interface{}{
+						"in": []interface{}{map[string]interface{}{"var": "country"}, []interface{}{"US", "CA"}},
+					},
+					Serve: Serve{Variant: "on"},
+				},
+				{
+					If: map[string]interface{}{
+						"===": []interface{}{map[string]interface{}{"var": "plan"}, "pro"},
+					},
+					Serve: Serve{Split: []Split{{Variant: "on", Weight: 25}, {Variant: "off", Weight: 75}}},
+				},
+			},
+			Default: Serve{Variant: "off"},
+		},
+		"limits": {
+			Enabled: true,
+			Variants: map[string]interface{}{
+				"small": map[string]interface{}{"max_items": float64(10), "tags": []interface{}{"a", "b"}},
+				"large": map[string]interface{}{"max_items": float64(100)},
+			},
+			OffVariant: "small",
+			Default:    Serve{Variant: "large"},
+		},
+	}, flags)
+	_, err = New(nil, flags)
+	assert.NoError(err)
+
+	// Same in json.
+	flags2, err := ParseJSON([]byte(`{"flags":{"limits":{"enabled":true,"variants":{"small":{"max_items":10,"tags":["a","b"]},"large":{"max_items":100}},"off_variant":"small","default":{"variant":"large"}}}}`))
+	assert.NoError(err)
+	assert.Equal(flags["limits"], flags2["limits"])
+
+	// Errors.
+	for _, s := range []string{
+		`{"flags":{"a":{"enabled":true,"varaints":{}}}}`,
+		`{"flags":{"a":{"enabled":"yes"}}}`,
+		`{"flags":{}} {}`,
+		`{}`,
+		`[`,
+	} {
+		_, err := ParseJSON([]byte(s))
+		assert.Error(err, s)
+	}
+	for _, s := range []string{
+		"flags:\n  a:\n    enabled: true\n    varaints: {}\n",
+		"flags:\n  a:\n    variants: {1: true}\n",
+		"flags:\n  a:\n    variants: {on: .nan}\n",
+		"flags: [",
+	} {
+		_, err := ParseYAML([]byte(s))
+		assert.Error(err, s)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "flags")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"flags.yml":  testYAML,
+		"flags.YAML": testYAML,
+		"flags.json": `{"flags":{"a":{"enabled":false,"variants":{"off":false},"off_variant":"off","default":{"variant":"off"}}}}`,
+		"flags.toml": ``,
+	}
+	for name, content := range files {
+		assert.NoError(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	flags, err := LoadFile(filepath.Join(dir, "flags.yml"))
+	assert.NoError(err)
+	assert.Len(flags, 2)
+	flags, err = LoadFile(filepath.Join(dir, "flags.YAML"))
+	assert.NoError(err)
+	assert.Len(flags, 2)
+	flags, err = LoadFile(filepath.Join(dir, "flags.json"))
+	assert.NoError(err)
+	assert.Len(flags, 1)
+
+	_, err = LoadFile(filepath.Join(dir, "flags.toml"))
+	assert.Error(err)
+	_, err = LoadFile(filepath.Join(dir, "missing.json"))
+	assert.Error(err)
+}
@@ -0,0 +1,184 @@
+package jsonlogic
+
+import (
+	"fmt"
+)
+
+// function is a user-defined function declared by "def".
+type function struct {
+	name   string
+	params []string
+	body   interface{}
+	// scope is the defining scope of the function, the body is evaluated in a child scope of it (lexical scoping).
+	scope *scope
+}
+
+// newFunction creates a function from its definition: {"params":[...],"body":...}.
+func newFunction(name string, def interface{}) (*function, error) {
+	m, ok := def.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("function %q: expect object as definition but got %T", name, def)
+	}
+
+	fn := &function{
+		name: name,
+	}
+	hasBody := false
+	for key, value := range m {
+		switch key {
+		case "params":
+			params, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("function %q: expect array for params but got %T", name, value)
+			}
+			seen := map[string]bool{}
+			for _, param := range params {
+				p, ok := param.(string)
+				if !ok || p == "" {
+					return nil, fmt.Errorf("function %q: expect non-empty string as param name but got %v", name, param)
+				}
+				if seen[p] {
+					return nil, fmt.Errorf("function %q: duplicated param name %q", name, p)
+				}
+				seen[p] = true
+				fn.params = append(fn.params, p)
+			}
+		case "body":
+			fn.body = value
+			hasBody = true
+		default:
+			return nil, fmt.Errorf("function %q: unknown field %q in definition", name, key)
+		}
+	}
+	if !hasBody {
+		return nil, fmt.Errorf("function %q: missing body", name)
+	}
+	return fn, nil
+}
+
+// AddOpDef adds "def" operation to the JSONLogic instance. Param restriction:
+//   - Two params: the first is an object mapping names to function definitions and the second the body logic.
+//
+// A function definition is an object {"params":[names...],"body":logic}, "params" can be omitted if the function
+// has no param. Functions are visible (see "call") in the body logic and inside all functions defined in the same
+// "def", so they can be recursive or mutually recursive. For example:
+//   logic: {"def":[
+//     {"discount":{"params":["p"],"body":{"*":[{"val":"p"},0.9]}}},
+//     {"call":["discount",{"var":"price"}]}
+//   ]}
+//   data: {"price":100}
+//   result will be 90
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpDef(jl *JSONLogic) {
+	jl.AddOperation("def", opDef)
+	jl.SetParamsValidator("def", validateDef)
+}
+
+func validateDef(validate func(logic interface{}) error, params []interface{}) error {
+	if len(params) != 2 {
+		return fmt.Errorf("Validate: def: expect 2 params")
+	}
+	defs, ok := params[0].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Validate: def: expect object for param 0 but got %T", params[0])
+	}
+	for name, def := range defs {
+		if name == "" {
+			return fmt.Errorf("Validate: def: function name must not be empty")
+		}
+		fn, err := newFunction(name, def)
+		if err != nil {
+			return fmt.Errorf("Validate: def: %s", err.Error())
+		}
+		if err := validate(fn.body); err != nil {
+			return err
+		}
+	}
+	return validate(params[1])
+}
+
+func opDef(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	if len(params) != 2 {
+		return nil, fmt.Errorf("def: expect 2 params")
+	}
+	defs, ok := params[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("def: expect object for param 0 but got %T", params[0])
+	}
+
+	frame := &scope{
+		parent: currentScope(apply),
+		funcs:  make(map[string]*function, len(defs)),
+	}
+	for name, def := range defs {
+		if name == "" {
+			return nil, fmt.Errorf("def: function name must not be empty")
+		}
+		fn, err := newFunction(name, def)
+		if err != nil {
+			return nil, fmt.Errorf("def: %s", err.Error())
+		}
+		fn.scope = frame
+		frame.funcs[name] = fn
+	}
+
+	return apply(scopedLogic{
+		logic: params[1],
+		frame: frame,
+	}, data)
+}
+
+// AddOpCall adds "call" operation to the JSONLogic instance. Param restriction:
+//   - At least one param: the first evaluated to the function name, and the rest are arguments.
+//   - The number of arguments must match the number of function params.
+//
+// Arguments are evaluated in the caller's scope, then the function body is evaluated against current data,
+// with arguments bound to param names (read by "val"). Only bindings/functions of the defining scope
+// (not the caller's) are visible in the body. The max recursion depth is limited by JSONLogic.SetMaxDepth.
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpCall(jl *JSONLogic) {
+	jl.AddOperation("call", opCall)
+}
+
+func opCall(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	if len(params) < 1 {
+		return nil, fmt.Errorf("call: expect at least one param")
+	}
+
+	r, err := apply(params[0], data)
+	if err != nil {
+		return nil, err
+	}
+	name, ok := r.(string)
+	if !ok {
+		return nil, fmt.Errorf("call: expect string as function name but got %T", r)
+	}
+
+	fn, found := currentScope(apply).lookupFunc(name)
+	if !found {
+		return nil, fmt.Errorf("call: function %q not found", name)
+	}
+
+	args := params[1:]
+	if len(args) != len(fn.params) {
+		return nil, fmt.Errorf("call: function %q expects %d arguments but got %d", name, len(fn.params), len(args))
+	}
+	args, err = ApplyParams(apply, args, data)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]interface{}, len(args))
+	for i, arg := range args {
+		vars[fn.params[i]] = arg
+	}
+	return apply(scopedLogic{
+		logic: fn.body,
+		frame: &scope{
+			parent: fn.scope,
+			vars:   vars,
+		},
+	}, data)
+}
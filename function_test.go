@@ -0,0 +1,108 @@
+package jsonlogic
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpDefCall(t *testing.T) {
+	assert := assert.New(t)
+	jl := NewEmpty()
+	AddOpVar(jl)
+	AddOpIf(jl)
+	AddOpLessEqual(jl)
+	AddOpStrictEqual(jl)
+	AddOpMinus(jl)
+	AddOpMul(jl)
+	AddOpMap(jl)
+	AddOpLet(jl)
+	AddOpVal(jl)
+	AddOpDef(jl)
+	AddOpCall(jl)
+	TestCases{
+		{Logic: `{"def":[{"discount":{"params":["p"],"body":{"*":[{"val":"p"},0.9]}}},{"call":["discount",{"var":"price"}]}]}`, Data: `{"price":100}`, Result: float64(90)},
+		// No params.
+		{Logic: `{"def":[{"one":{"body":1}},{"call":"one"}]}`, Data: `null`, Result: float64(1)},
+		{Logic: `{"def":[{"one":{"params":[],"body":1}},{"call":["one"]}]}`, Data: `null`, Result: float64(1)},
+		// Body can read current data.
+		{Logic: `{"def":[{"price":{"body":{"var":"price"}}},{"map":[{"var":"items"},{"call":"price"}]}]}`, Data: `{"items":[{"price":1},{"price":2}]}`, Result: []interface{}{float64(1), float64(2)}},
+		// Function name from logic.
+		{Logic: `{"def":[{"f":{"body":"F"}},{"call":{"var":"fn"}}]}`, Data: `{"fn":"f"}`, Result: "F"},
+		// Recursion.
+		{Logic: `{"def":[{"fact":{"params":["n"],"body":{"if":[{"<=":[{"val":"n"},1]},1,{"*":[{"val":"n"},{"call":["fact",{"-":[{"val":"n"},1]}]}]}]}}},{"call":["fact",5]}]}`, Data: `null`, Result: float64(120)},
+		// Mutual recursion.
+		{Logic: `{"def":[{"even":{"params":["n"],"body":{"if":[{"===":[{"val":"n"},0]},true,{"call":["odd",{"-":[{"val":"n"},1]}]}]}},"odd":{"params":["n"],"body":{"if":[{"===":[{"val":"n"},0]},false,{"call":["even",{"-":[{"val":"n"},1]}]}]}}},{"call":["even",10]}]}`, Data: `null`, Result: true},
+		// Lexical scoping: bindings of the defining scope are visible.
+		{Logic: `{"let":[{"rate":0.5},{"def":[{"f":{"params":["p"],"body":{"*":[{"val":"p"},{"val":"rate"}]}}},{"call":["f",4]}]}]}`, Data: `null`, Result: float64(2)},
+		// Lexical scoping: bindings of the caller's scope are not visible.
+		{Logic: `{"def":[{"f":{"body":{"val":"x"}}},{"let":[{"x":1},{"call":"f"}]}]}`, Data: `null`, Result: nil},
+		// Params shadow outer bindings.
+		{Logic: `{"let":[{"p":1},{"def":[{"f":{"params":["p"],"body":{"val":"p"}}},{"call":["f",2]}]}]}`, Data: `null`, Result: float64(2)},
+		// Inner def shadows outer one.
+		{Logic: `{"def":[{"f":{"body":1}},{"def":[{"f":{"body":2}},{"call":"f"}]}]}`, Data: `null`, Result: float64(2)},
+		// Functions are out of scope after def.
+		{Logic: `[{"def":[{"f":{"body":1}},{"call":"f"}]},{"call":"f"}]`, Data: `null`, Err: true},
+		// Argument count.
+		{Logic: `{"def":[{"f":{"params":["a","b"],"body":1}},{"call":["f",1]}]}`, Data: `null`, Err: true},
+		{Logic: `{"def":[{"f":{"params":["a"],"body":1}},{"call":["f",1,2]}]}`, Data: `null`, Err: true},
+		// Bad definition.
+		{Logic: `{"def":[{"f":{"params":["a"]}},1]}`, Data: `null`, Err: true},
+		{Logic: `{"def":[{"f":{"params":["a","a"],"body":1}},1]}`, Data: `null`, Err: true},
+		{Logic: `{"def":[{"f":{"params":[1],"body":1}},1]}`, Data: `null`, Err: true},
+		{Logic: `{"def":[{"f":{"params":"a","body":1}},1]}`, Data: `null`, Err: true},
+		{Logic: `{"def":[{"f":{"body":1,"x":1}},1]}`, Data: `null`, Err: true},
+		{Logic: `{"def":[{"f":1},1]}`, Data: `null`, Err: true},
+		{Logic: `{"def":[{"":{"body":1}},1]}`, Data: `null`, Err: true},
+		{Logic: `{"def":[[],1]}`, Data: `null`, Err: true},
+		{Logic: `{"def":{"f":{"body":1}}}`, Data: `null`, Err: true},
+		// Bad call.
+		{Logic: `{"call":"f"}`, Data: `null`, Err: true},
+		{Logic: `{"call":[]}`, Data: `null`, Err: true},
+		{Logic: `{"def":[{"f":{"body":1}},{"call":1}]}`, Data: `null`, Err: true},
+		// Infinite recursion.
+		{Logic: `{"def":[{"f":{"body":{"call":"f"}}},{"call":"f"}]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
+
+func TestMaxDepth(t *testing.T) {
+	assert := assert.New(t)
+
+	var logic interface{}
+	assert.NoError(json.Unmarshal([]byte(`{"def":[{"f":{"params":["n"],"body":{"if":[{"<=":[{"val":"n"},0]},0,{"call":["f",{"-":[{"val":"n"},1]}]}]}}},{"call":["f",{"var":"n"}]}]}`), &logic))
+
+	parent := NewEmpty()
+	AddOpVar(parent)
+	AddOpIf(parent)
+	AddOpLessEqual(parent)
+	AddOpMinus(parent)
+	AddOpVal(parent)
+	AddOpDef(parent)
+	AddOpCall(parent)
+	assert.Equal(DefaultMaxDepth, parent.MaxDepth())
+
+	{
+		_, err := parent.Apply(logic, map[string]interface{}{"n": float64(10)})
+		assert.NoError(err)
+		_, err = parent.Apply(logic, map[string]interface{}{"n": float64(1000)})
+		assert.True(errors.Is(err, ErrMaxDepthExceeded))
+	}
+
+	child := NewInherit(parent)
+	parent.SetMaxDepth(20)
+	assert.Equal(20, child.MaxDepth())
+	{
+		_, err := child.Apply(logic, map[string]interface{}{"n": float64(10)})
+		assert.True(errors.Is(err, ErrMaxDepthExceeded))
+	}
+
+	child.SetMaxDepth(-1)
+	assert.Equal(-1, child.MaxDepth())
+	assert.Equal(-1, child.Clone().MaxDepth())
+	{
+		_, err := child.Apply(logic, map[string]interface{}{"n": float64(1000)})
+		assert.NoError(err)
+	}
+}
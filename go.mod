@@ -2,4 +2,7 @@ module github.com/huangjunwen/jsonlogic-go
 
 go 1.14
 
-require github.com/stretchr/testify v1.6.1
+require (
+	github.com/stretchr/testify v1.6.1
+	gopkg.in/yaml.v3 v3.0.1
+)
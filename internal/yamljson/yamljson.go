@@ -0,0 +1,77 @@
+// Package yamljson converts YAML documents to json.
+package yamljson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ToJSON converts a YAML document to json. Maps must have string keys, and values must be representable
+// in json (e.g. no .nan/.inf).
+func ToJSON(b []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	doc, err := convert(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// Unmarshal parses a YAML document into json types: nil, bool, float64, string, []interface{} and
+// map[string]interface{}.
+func Unmarshal(b []byte) (interface{}, error) {
+	j, err := ToJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	var ret interface{}
+	if err := json.Unmarshal(j, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// convert converts maps with non-string keys decoded from YAML, which can't be marshaled to json.
+func convert(obj interface{}) (interface{}, error) {
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		for k, v := range o {
+			v, err := convert(v)
+			if err != nil {
+				return nil, err
+			}
+			o[k] = v
+		}
+		return o, nil
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(o))
+		for k, v := range o {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("expect string as map key but got %T %v", k, k)
+			}
+			v, err := convert(v)
+			if err != nil {
+				return nil, err
+			}
+			ret[key] = v
+		}
+		return ret, nil
+	case []interface{}:
+		for i, item := range o {
+			item, err := convert(item)
+			if err != nil {
+				return nil, err
+			}
+			o[i] = item
+		}
+		return o, nil
+	default:
+		return obj, nil
+	}
+}
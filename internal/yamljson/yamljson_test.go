@@ -0,0 +1,32 @@
+package yamljson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshal(t *testing.T) {
+	assert := assert.New(t)
+
+	v, err := Unmarshal([]byte("a: [1, 1.5, x, true, null]\nb: {c: {d: 2}}\n"))
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{
+		"a": []interface{}{1.0, 1.5, "x", true, nil},
+		"b": map[string]interface{}{"c": map[string]interface{}{"d": 2.0}},
+	}, v)
+
+	j, err := ToJSON([]byte(`{"if": [{"var": "a"}, "yes", "no"]}`))
+	assert.NoError(err)
+	assert.Equal(`{"if":[{"var":"a"},"yes","no"]}`, string(j))
+
+	for _, s := range []string{
+		"a: {1: x}",
+		"a: [{[1]: x}]",
+		"a: .nan",
+		"a: [",
+	} {
+		_, err := Unmarshal([]byte(s))
+		assert.Error(err, s)
+	}
+}
@@ -17,10 +17,42 @@ func outputErrorAndExit(err error) {
 
 func init() {
 	// Add extensions.
+	jsonlogic.AddOpLet(jsonlogic.DefaultJSONLogic)
+	jsonlogic.AddOpVal(jsonlogic.DefaultJSONLogic)
+	jsonlogic.AddOpIndex(jsonlogic.DefaultJSONLogic)
+	jsonlogic.AddOpDef(jsonlogic.DefaultJSONLogic)
+	jsonlogic.AddOpCall(jsonlogic.DefaultJSONLogic)
+	jsonlogic.AddOpCoalesce(jsonlogic.DefaultJSONLogic)
+	jsonlogic.AddOpExists(jsonlogic.DefaultJSONLogic)
+	jsonlogic.AddOpDefault(jsonlogic.DefaultJSONLogic)
+	jsonlogic.AddOpTry(jsonlogic.DefaultJSONLogic)
+	jsonlogic.AddOpThrow(jsonlogic.DefaultJSONLogic)
+	jsonlogic.AddOpSwitch(jsonlogic.DefaultJSONLogic)
+	jsonlogic.AddOpCond(jsonlogic.DefaultJSONLogic)
 	ext.AddOpRange(jsonlogic.DefaultJSONLogic)
+	ext.AddStringOps(jsonlogic.DefaultJSONLogic)
+	ext.AddRegexOps(jsonlogic.DefaultJSONLogic, nil)
+	ext.AddDatetimeOps(jsonlogic.DefaultJSONLogic)
+	ext.AddMathOps(jsonlogic.DefaultJSONLogic)
+	ext.AddArrayOps(jsonlogic.DefaultJSONLogic)
+	ext.AddAggregateOps(jsonlogic.DefaultJSONLogic)
+	ext.AddObjectOps(jsonlogic.DefaultJSONLogic)
+	ext.AddEqualityOps(jsonlogic.DefaultJSONLogic)
+	ext.AddTypeOps(jsonlogic.DefaultJSONLogic)
+	ext.AddSemverOps(jsonlogic.DefaultJSONLogic)
+	ext.AddIPOps(jsonlogic.DefaultJSONLogic, nil)
+	ext.AddGeoOps(jsonlogic.DefaultJSONLogic)
+	ext.AddHashOps(jsonlogic.DefaultJSONLogic)
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := serveMain(os.Args[2:]); err != nil {
+			outputErrorAndExit(err)
+		}
+		return
+	}
+
 	var (
 		logic, data interface{}
 	)
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/huangjunwen/jsonlogic-go"
+	"github.com/huangjunwen/jsonlogic-go/store"
+)
+
+const (
+	defaultMaxBodySize = 1 << 20
+	defaultTimeout     = 5 * time.Second
+)
+
+// server is the http evaluation service of "jl serve".
+type server struct {
+	jl          *jsonlogic.JSONLogic
+	rules       *store.Store
+	maxBodySize int64
+	timeout     time.Duration
+}
+
+// serveMain runs "jl serve" with command line args.
+func serveMain(args []string) error {
+	fs := flag.NewFlagSet("jl serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "listen address")
+	rulesDir := fs.String("rules", "", "directory of named rules (json/yaml files), disabled if empty")
+	poll := fs.Duration("poll", 5*time.Second, "interval to reload rules, 0 to disable")
+	maxBodySize := fs.Int64("max-body", defaultMaxBodySize, "max request body size in bytes")
+	timeout := fs.Duration("timeout", defaultTimeout, "max evaluation time per request")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	s := &server{
+		jl:          jsonlogic.DefaultJSONLogic,
+		maxBodySize: *maxBodySize,
+		timeout:     *timeout,
+	}
+	if *rulesDir != "" {
+		rules, err := store.Open(*rulesDir, &store.Options{
+			JSONLogic:    s.jl,
+			PollInterval: *poll,
+			OnReload: func(snap *store.Snapshot) {
+				fmt.Fprintf(os.Stderr, "rules version %d loaded: %d rules\n", snap.Version, len(snap.Rules))
+			},
+			OnError: func(err error) {
+				fmt.Fprintln(os.Stderr, err)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		defer rules.Close()
+		s.rules = rules
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "listening on %s\n", *addr)
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-errc:
+		return err
+	case <-sigc:
+		return srv.Close()
+	}
+}
+
+// handler returns the http handler of all endpoints:
+//   - POST /v1/evaluate {"logic":...,"data":...} -> {"result":...}
+//   - POST /v1/rules/<name>/evaluate {"data":...} -> {"result":...,"rule":...,"version":...}
+//   - GET /v1/rules -> {"version":...,"rules":[...]}
+//   - POST /v1/validate {"logic":...} -> {"valid":true} or {"valid":false,"error":"..."}
+//   - GET /v1/operators -> {"operators":[...]}
+//
+// Errors are {"error":{"code":"...","message":"..."}} with http status codes, errors raised by "throw"
+// have an extra "thrown" field {"code":"...","message":"..."}.
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/evaluate", s.method(http.MethodPost, s.handleEvaluate))
+	mux.HandleFunc("/v1/rules", s.method(http.MethodGet, s.handleListRules))
+	mux.HandleFunc("/v1/rules/", s.method(http.MethodPost, s.handleEvaluateRule))
+	mux.HandleFunc("/v1/validate", s.method(http.MethodPost, s.handleValidate))
+	mux.HandleFunc("/v1/operators", s.method(http.MethodGet, s.handleOperators))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("no endpoint %s", r.URL.Path), nil)
+	})
+	return mux
+}
+
+// method restricts the handler to a http method.
+func (s *server) method(method string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", fmt.Sprintf("expect %s but got %s", method, r.Method), nil)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// errorBody is the body of error responses.
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Thrown  *thrownError `json:"thrown,omitempty"`
+}
+
+type thrownError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		b, _ = json.Marshal(errorBody{Error: errorDetail{Code: "internal", Message: err.Error()}})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+	w.Write([]byte("\n"))
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string, thrown *jsonlogic.ThrownError) {
+	body := errorBody{Error: errorDetail{Code: code, Message: message}}
+	if thrown != nil {
+		body.Error.Thrown = &thrownError{Code: thrown.Code, Message: thrown.Message}
+	}
+	writeJSON(w, status, body)
+}
+
+// decodeBody decodes the json request body into v, unknown fields are errors. It writes the error response
+// and returns false on error.
+func (s *server) decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	b, err := ioutil.ReadAll(io.LimitReader(r.Body, s.maxBodySize+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error(), nil)
+		return false
+	}
+	if int64(len(b)) > s.maxBodySize {
+		writeError(w, http.StatusRequestEntityTooLarge, "body_too_large", fmt.Sprintf("request body exceeds %d bytes", s.maxBodySize), nil)
+		return false
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("invalid json body: %s", err.Error()), nil)
+		return false
+	}
+	if dec.More() {
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid json body: unexpected data after body", nil)
+		return false
+	}
+	return true
+}
+
+// apply evaluates logic, the evaluation stops when the timeout of the server is exceeded or the request is
+// canceled (see jsonlogic.JSONLogic.ApplyContext).
+func (s *server) apply(r *http.Request, logic, data interface{}) (interface{}, error) {
+	ctx := r.Context()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	return s.jl.ApplyContext(ctx, logic, data)
+}
+
+// writeResult writes the result of evaluation, or the error.
+func writeResult(w http.ResponseWriter, body map[string]interface{}, res interface{}, err error) {
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			writeError(w, http.StatusServiceUnavailable, "timeout", "evaluation timeout or canceled", nil)
+			return
+		}
+		// thrown is nil if the error is not raised by "throw".
+		var thrown *jsonlogic.ThrownError
+		errors.As(err, &thrown)
+		writeError(w, http.StatusUnprocessableEntity, "evaluation_error", err.Error(), thrown)
+		return
+	}
+	body["result"] = res
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *server) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Logic json.RawMessage `json:"logic"`
+		Data  interface{}     `json:"data"`
+	}
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+	if len(req.Logic) == 0 {
+		writeError(w, http.StatusBadRequest, "bad_request", "missing \"logic\"", nil)
+		return
+	}
+	var logic interface{}
+	if err := json.Unmarshal(req.Logic, &logic); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error(), nil)
+		return
+	}
+	res, err := s.apply(r, logic, req.Data)
+	writeResult(w, map[string]interface{}{}, res, err)
+}
+
+func (s *server) handleEvaluateRule(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/v1/rules/")
+	if !strings.HasSuffix(name, "/evaluate") || strings.Count(name, "/") != 1 {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("no endpoint %s", r.URL.Path), nil)
+		return
+	}
+	name = strings.TrimSuffix(name, "/evaluate")
+	if s.rules == nil {
+		writeError(w, http.StatusNotFound, "rules_disabled", "no rules directory", nil)
+		return
+	}
+
+	var req struct {
+		Data interface{} `json:"data"`
+	}
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+	// Use the same version during the request.
+	snap := s.rules.Current()
+	rule, ok := snap.Rule(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "rule_not_found", fmt.Sprintf("rule %q not found", name), nil)
+		return
+	}
+	res, err := s.apply(r, rule.Logic, req.Data)
+	writeResult(w, map[string]interface{}{"rule": name, "version": snap.Version}, res, err)
+}
+
+func (s *server) handleListRules(w http.ResponseWriter, r *http.Request) {
+	if s.rules == nil {
+		writeError(w, http.StatusNotFound, "rules_disabled", "no rules directory", nil)
+		return
+	}
+	snap := s.rules.Current()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"version": snap.Version,
+		"rules":   snap.Names(),
+	})
+}
+
+func (s *server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Logic json.RawMessage `json:"logic"`
+	}
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+	if len(req.Logic) == 0 {
+		writeError(w, http.StatusBadRequest, "bad_request", "missing \"logic\"", nil)
+		return
+	}
+	var logic interface{}
+	if err := json.Unmarshal(req.Logic, &logic); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error(), nil)
+		return
+	}
+	if err := s.jl.Validate(logic); err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"valid": false, "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"valid": true})
+}
+
+func (s *server) handleOperators(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"operators": s.jl.Operations()})
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/huangjunwen/jsonlogic-go"
+	"github.com/huangjunwen/jsonlogic-go/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// ticks counts evaluations of the "tick" operation of test servers.
+var ticks int64
+
+// slowLogic runs about 1s.
+const slowLogic = `{"map":[{"range":1000},{"tick":[]}]}`
+
+func newTestServer(t *testing.T, withRules bool) (*server, func()) {
+	assert := assert.New(t)
+
+	jl := jsonlogic.NewInherit(jsonlogic.DefaultJSONLogic)
+	jl.AddOperation("tick", func(apply jsonlogic.Applier, params []interface{}, data interface{}) (interface{}, error) {
+		atomic.AddInt64(&ticks, 1)
+		time.Sleep(time.Millisecond)
+		return nil, nil
+	})
+	s := &server{
+		jl:          jl,
+		maxBodySize: 256,
+		timeout:     50 * time.Millisecond,
+	}
+	if !withRules {
+		return s, func() {}
+	}
+
+	dir, err := ioutil.TempDir("", "jl-serve")
+	assert.NoError(err)
+	files := map[string]string{
+		"discount.json": `{"if":[{">=":[{"var":"age"},65]},0.2,0]}`,
+		"blocked.yaml":  "throw: [blocked, user is blocked]\n",
+		"slow.json":     slowLogic,
+		"total.json":    `{"let":[{"total":{"+":[{"var":"a"},{"var":"b"}]}},{"*":[{"val":"total"},2]}]}`,
+	}
+	for file, content := range files {
+		assert.NoError(ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+	}
+	rules, err := store.Open(dir, &store.Options{JSONLogic: jl})
+	if !assert.NoError(err) {
+		os.RemoveAll(dir)
+		t.FailNow()
+	}
+	s.rules = rules
+	return s, func() {
+		rules.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func doRequest(t *testing.T, ts *httptest.Server, method, path, body string) (int, map[string]interface{}) {
+	assert := assert.New(t)
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	assert.NoError(err)
+	resp, err := ts.Client().Do(req)
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+	assert.Equal("application/json", resp.Header.Get("Content-Type"))
+	var ret map[string]interface{}
+	assert.NoError(json.NewDecoder(resp.Body).Decode(&ret))
+	return resp.StatusCode, ret
+}
+
+func errorCode(body map[string]interface{}) interface{} {
+	e, ok := body["error"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return e["code"]
+}
+
+func TestServe(t *testing.T) {
+	assert := assert.New(t)
+	s, cleanup := newTestServer(t, true)
+	defer cleanup()
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+
+	for i, testCase := range []struct {
+		Method     string
+		Path       string
+		Body       string
+		Status     int
+		ErrorCode  interface{}
+		Result     interface{}
+		CheckExtra func(body map[string]interface{})
+	}{
+		// Evaluate.
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"logic":{"+":[{"var":"a"},1]},"data":{"a":2}}`, Status: 200, Result: 3.0},
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"logic":{"var":""},"data":[1]}`, Status: 200, Result: []interface{}{1.0}},
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"logic":null}`, Status: 200, Result: nil},
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"data":{}}`, Status: 400, ErrorCode: "bad_request"},
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"logic":1,"extra":1}`, Status: 400, ErrorCode: "bad_request"},
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"logic":1} {}`, Status: 400, ErrorCode: "bad_request"},
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"logic":`, Status: 400, ErrorCode: "bad_request"},
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"logic":{"nop":[]}}`, Status: 422, ErrorCode: "evaluation_error"},
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"logic":{"throw":["oops","bad"]}}`, Status: 422, ErrorCode: "evaluation_error",
+			CheckExtra: func(body map[string]interface{}) {
+				assert.Equal(map[string]interface{}{"code": "oops", "message": "bad"}, body["error"].(map[string]interface{})["thrown"])
+			}},
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"logic":` + slowLogic + `}`, Status: 503, ErrorCode: "timeout"},
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"logic":{"range":1e12}}`, Status: 422, ErrorCode: "evaluation_error"},
+		{Method: "POST", Path: "/v1/evaluate", Body: `{"logic":"` + strings.Repeat("x", 256) + `"}`, Status: 413, ErrorCode: "body_too_large"},
+		{Method: "GET", Path: "/v1/evaluate", Status: 405, ErrorCode: "method_not_allowed"},
+		// Named rules.
+		{Method: "POST", Path: "/v1/rules/discount/evaluate", Body: `{"data":{"age":70}}`, Status: 200, Result: 0.2,
+			CheckExtra: func(body map[string]interface{}) {
+				assert.Equal("discount", body["rule"])
+				assert.Equal(1.0, body["version"])
+			}},
+		{Method: "POST", Path: "/v1/rules/discount/evaluate", Body: `{}`, Status: 200, Result: 0.0},
+		{Method: "POST", Path: "/v1/rules/blocked/evaluate", Body: `{}`, Status: 422, ErrorCode: "evaluation_error",
+			CheckExtra: func(body map[string]interface{}) {
+				assert.Equal(map[string]interface{}{"code": "blocked", "message": "user is blocked"}, body["error"].(map[string]interface{})["thrown"])
+			}},
+		{Method: "POST", Path: "/v1/rules/slow/evaluate", Body: `{}`, Status: 503, ErrorCode: "timeout"},
+		{Method: "POST", Path: "/v1/rules/total/evaluate", Body: `{"data":{"a":1,"b":2}}`, Status: 200, Result: 6.0},
+		{Method: "POST", Path: "/v1/rules/missing/evaluate", Body: `{}`, Status: 404, ErrorCode: "rule_not_found"},
+		{Method: "POST", Path: "/v1/rules/discount/evaluate", Body: `{"logic":1}`, Status: 400, ErrorCode: "bad_request"},
+		{Method: "POST", Path: "/v1/rules/discount", Body: `{}`, Status: 404, ErrorCode: "not_found"},
+		{Method: "POST", Path: "/v1/rules/a/b/evaluate", Body: `{}`, Status: 404, ErrorCode: "not_found"},
+		{Method: "GET", Path: "/v1/rules", Status: 200,
+			CheckExtra: func(body map[string]interface{}) {
+				assert.Equal(1.0, body["version"])
+				assert.Equal([]interface{}{"blocked", "discount", "slow", "total"}, body["rules"])
+			}},
+		// Validate.
+		{Method: "POST", Path: "/v1/validate", Body: `{"logic":{"if":[{"var":"a"},1,2]}}`, Status: 200,
+			CheckExtra: func(body map[string]interface{}) {
+				assert.Equal(map[string]interface{}{"valid": true}, body)
+			}},
+		{Method: "POST", Path: "/v1/validate", Body: `{"logic":{"if":[{"nop":1},1,2]}}`, Status: 200,
+			CheckExtra: func(body map[string]interface{}) {
+				assert.Equal(false, body["valid"])
+				assert.Contains(body["error"], `"nop"`)
+			}},
+		{Method: "POST", Path: "/v1/validate", Body: `{}`, Status: 400, ErrorCode: "bad_request"},
+		{Method: "POST", Path: "/v1/validate", Body: `{"logic":{"let":[{"total":{"var":"a"}},{"*":[{"val":"total"},2]}]}}`, Status: 200,
+			CheckExtra: func(body map[string]interface{}) {
+				assert.Equal(map[string]interface{}{"valid": true}, body)
+			}},
+		{Method: "POST", Path: "/v1/validate", Body: `{"logic":{"def":[{"f":{"body":1}},{"call":"f"}]}}`, Status: 200,
+			CheckExtra: func(body map[string]interface{}) {
+				assert.Equal(map[string]interface{}{"valid": true}, body)
+			}},
+		{Method: "POST", Path: "/v1/validate", Body: `{"logic":{"preserve":{"a":1}}}`, Status: 200,
+			CheckExtra: func(body map[string]interface{}) {
+				assert.Equal(map[string]interface{}{"valid": true}, body)
+			}},
+		// Operators.
+		{Method: "GET", Path: "/v1/operators", Status: 200,
+			CheckExtra: func(body map[string]interface{}) {
+				ops := body["operators"].([]interface{})
+				assert.Contains(ops, "if")
+				assert.Contains(ops, "tick")
+			}},
+		{Method: "POST", Path: "/v1/operators", Status: 405, ErrorCode: "method_not_allowed"},
+		// Unknown.
+		{Method: "GET", Path: "/v2/evaluate", Status: 404, ErrorCode: "not_found"},
+	} {
+		status, body := doRequest(t, ts, testCase.Method, testCase.Path, testCase.Body)
+		assert.Equal(testCase.Status, status, "test case %d", i)
+		assert.Equal(testCase.ErrorCode, errorCode(body), "test case %d", i)
+		if status == 200 {
+			if _, ok := body["result"]; ok || testCase.Result != nil {
+				assert.Equal(testCase.Result, body["result"], "test case %d", i)
+			}
+		}
+		if testCase.CheckExtra != nil {
+			testCase.CheckExtra(body)
+		}
+	}
+}
+
+func TestServeWithoutRules(t *testing.T) {
+	assert := assert.New(t)
+	s, cleanup := newTestServer(t, false)
+	defer cleanup()
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+
+	status, body := doRequest(t, ts, "GET", "/v1/rules", "")
+	assert.Equal(404, status)
+	assert.Equal("rules_disabled", errorCode(body))
+
+	status, body = doRequest(t, ts, "POST", "/v1/rules/discount/evaluate", `{}`)
+	assert.Equal(404, status)
+	assert.Equal("rules_disabled", errorCode(body))
+
+	status, body = doRequest(t, ts, "POST", "/v1/evaluate", `{"logic":{"===":[1,1]}}`)
+	assert.Equal(200, status)
+	assert.Equal(true, body["result"])
+}
+
+func TestServeTimeoutStopsEvaluation(t *testing.T) {
+	assert := assert.New(t)
+	s, cleanup := newTestServer(t, false)
+	defer cleanup()
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+
+	start := atomic.LoadInt64(&ticks)
+	status, body := doRequest(t, ts, "POST", "/v1/evaluate", `{"logic":`+slowLogic+`}`)
+	assert.Equal(503, status)
+	assert.Equal("timeout", errorCode(body))
+
+	// No more ticks after the response.
+	n := atomic.LoadInt64(&ticks) - start
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(n, atomic.LoadInt64(&ticks)-start)
+	assert.True(n < 1000, n)
+}
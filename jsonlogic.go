@@ -1,7 +1,11 @@
 package jsonlogic
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"time"
 )
 
 var (
@@ -9,21 +13,39 @@ var (
 	DefaultJSONLogic = New()
 )
 
+// ErrMaxDepthExceeded is returned (wrapped) by Apply when the max depth of nested operations is exceeded.
+var ErrMaxDepthExceeded = errors.New("max depth exceeded")
+
+// DefaultMaxDepth is the default max depth of nested operations in an evaluation. See SetMaxDepth.
+const DefaultMaxDepth = 512
+
 // JSONLogic is an evaluator of json logic with a set of operations.
 type JSONLogic struct {
-	parent *JSONLogic
-	ops    map[string]Operation
+	parent     *JSONLogic
+	ops        map[string]Operation
+	validators map[string]ParamsValidator
+	maxDepth   int
+	pathSyntax PathSyntax
+	clock      Clock
 }
 
+// Clock returns the current time.
+type Clock func() time.Time
+
 type Applier func(logic, data interface{}) (res interface{}, err error)
 
 type Operation func(apply Applier, params []interface{}, data interface{}) (interface{}, error)
 
+// ParamsValidator checks params of an operation for JSONLogic.Validate, validate checks a logic (e.g. a param,
+// or part of it, which the operation evaluates). See JSONLogic.SetParamsValidator.
+type ParamsValidator func(validate func(logic interface{}) error, params []interface{}) error
+
 // NewInherit creates a child JSONLogic instance.
 func NewInherit(parent *JSONLogic) *JSONLogic {
 	return &JSONLogic{
-		parent: parent,
-		ops:    make(map[string]Operation),
+		parent:     parent,
+		ops:        make(map[string]Operation),
+		validators: make(map[string]ParamsValidator),
 	}
 }
 
@@ -71,7 +93,8 @@ func New() *JSONLogic {
 // NewEmpty creates a root (no parent) JSONLogic with no operation.
 func NewEmpty() *JSONLogic {
 	return &JSONLogic{
-		ops: make(map[string]Operation),
+		ops:        make(map[string]Operation),
+		validators: make(map[string]ParamsValidator),
 	}
 }
 
@@ -88,11 +111,64 @@ func Apply(logic, data interface{}) (res interface{}, err error) {
 //   - []interface{} with items of supported types
 //   - map[string]interface{} with values of supported types
 func (jl *JSONLogic) Apply(logic, data interface{}) (res interface{}, err error) {
+	return jl.ApplyContext(context.Background(), logic, data)
+}
+
+// ApplyContext is equivalent to DefaultJSONLogic.ApplyContext.
+func ApplyContext(ctx context.Context, logic, data interface{}) (res interface{}, err error) {
+	return DefaultJSONLogic.ApplyContext(ctx, logic, data)
+}
+
+// ApplyContext is the same as Apply except that evaluation stops with an error wrapping ctx.Err() once ctx
+// is done. ctx is checked before each operation, so a long running operation itself (e.g. a custom one doing
+// heavy work without evaluating params) is not interrupted.
+func (jl *JSONLogic) ApplyContext(ctx context.Context, logic, data interface{}) (res interface{}, err error) {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	ev := &evaluation{
+		jl:         jl,
+		maxDepth:   jl.MaxDepth(),
+		pathSyntax: jl.PathSyntax(),
+		clock:      jl.Clock(),
+		ctx:        ctx,
+		done:       ctx.Done(),
+	}
+	return ev.apply(&scope{data: data, hasData: true}, 0, logic, data)
+}
+
+// evaluation holds the states of a single Apply.
+type evaluation struct {
+	jl         *JSONLogic
+	maxDepth   int
+	pathSyntax PathSyntax
+	clock      Clock
+	now        *time.Time
+	ctx        context.Context
+	// done is ctx.Done(), nil if ctx can never be done.
+	done <-chan struct{}
+}
+
+// apply evaluates logic against data in scope sc, depth is the number of enclosing operations.
+func (ev *evaluation) apply(sc *scope, depth int, logic, data interface{}) (res interface{}, err error) {
+	switch l := logic.(type) {
+	case scopedLogic:
+		// Push a new scope. Keep the parent if it has been set (e.g. the defining scope of a function).
+		if l.frame.parent == nil {
+			l.frame.parent = sc
+		}
+		return ev.apply(l.frame, depth, l.logic, data)
+	case scopeQuery:
+		return sc, nil
+	case evaluationQuery:
+		return ev, nil
+	}
+
 	// An array of rules.
 	if arr, ok := logic.([]interface{}); ok {
 		ret := []interface{}{}
 		for _, item := range arr {
-			res, err := jl.Apply(item, data)
+			res, err := ev.apply(sc, depth, item, data)
 			if err != nil {
 				return nil, err
 			}
@@ -122,8 +198,19 @@ func (jl *JSONLogic) Apply(logic, data interface{}) (res interface{}, err error)
 		}
 	}()
 
+	if ev.maxDepth > 0 && depth >= ev.maxDepth {
+		return nil, fmt.Errorf("Apply: %w (%d)", ErrMaxDepthExceeded, ev.maxDepth)
+	}
+	if ev.done != nil {
+		select {
+		case <-ev.done:
+			return nil, fmt.Errorf("Apply: %w", ev.ctx.Err())
+		default:
+		}
+	}
+
 	var opFn Operation
-	for inst := jl; inst != nil; inst = inst.parent {
+	for inst := ev.jl; inst != nil; inst = inst.parent {
 		var ok bool
 		opFn, ok = inst.ops[op]
 		if ok {
@@ -134,7 +221,9 @@ func (jl *JSONLogic) Apply(logic, data interface{}) (res interface{}, err error)
 		return nil, fmt.Errorf("Apply: operator %q not found", op)
 	}
 
-	return opFn(jl.Apply, params, data)
+	return opFn(func(logic, data interface{}) (interface{}, error) {
+		return ev.apply(sc, depth+1, logic, data)
+	}, params, data)
 }
 
 // AddOperation is equivalent to DefaultJSONLogic.AddOperation.
@@ -143,9 +232,146 @@ func AddOperation(name string, op Operation) {
 }
 
 // AddOperation adds a named operation to JSONLogic instance.
-// Can override parent's same name operation.
+// Can override parent's same name operation. It also removes the ParamsValidator of the same name.
 func (jl *JSONLogic) AddOperation(name string, op Operation) {
 	jl.ops[name] = op
+	delete(jl.validators, name)
+}
+
+// SetParamsValidator sets the ParamsValidator of the named operation in the JSONLogic instance, call it after
+// AddOperation. It is needed only if some params of the operation are not logic, e.g. the bindings object
+// of "let" whose values are logic but itself is not.
+func (jl *JSONLogic) SetParamsValidator(name string, v ParamsValidator) {
+	jl.validators[name] = v
+}
+
+// HasOperation returns true if the named operation is in the JSONLogic instance or its parents.
+func (jl *JSONLogic) HasOperation(name string) bool {
+	for inst := jl; inst != nil; inst = inst.parent {
+		if _, ok := inst.ops[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Operations returns sorted names of all operations in the JSONLogic instance and its parents.
+func (jl *JSONLogic) Operations() []string {
+	seen := map[string]struct{}{}
+	ret := []string{}
+	for inst := jl; inst != nil; inst = inst.parent {
+		for name := range inst.ops {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Validate checks logic statically without data: all operators used must exist in the JSONLogic instance.
+// Like Apply, objects with exactly one key are logic and other objects are data. Params of an operation are
+// checked as logic, or by its ParamsValidator if any (see SetParamsValidator).
+func (jl *JSONLogic) Validate(logic interface{}) error {
+	switch l := logic.(type) {
+	case []interface{}:
+		for _, item := range l {
+			if err := jl.Validate(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if !isLogic(l) {
+			return nil
+		}
+		op, params := getLogic(l)
+		found := false
+		for inst := jl; inst != nil; inst = inst.parent {
+			if _, found = inst.ops[op]; found {
+				// Only the validator added along with the operation.
+				if v := inst.validators[op]; v != nil {
+					return v(jl.Validate, params)
+				}
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("Validate: operator %q not found", op)
+		}
+		for _, param := range params {
+			if err := jl.Validate(param); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetMaxDepth sets the max depth of nested operations in an evaluation, an error is returned by Apply
+// when exceeded. This protects against runaway recursion (e.g. user-defined functions, see "def"/"call").
+// n == 0 means the same as parent's (or DefaultMaxDepth if no parent), n < 0 means no limit.
+func (jl *JSONLogic) SetMaxDepth(n int) {
+	jl.maxDepth = n
+}
+
+// MaxDepth returns the max depth of nested operations in an evaluation. See SetMaxDepth.
+func (jl *JSONLogic) MaxDepth() int {
+	for inst := jl; inst != nil; inst = inst.parent {
+		if inst.maxDepth != 0 {
+			return inst.maxDepth
+		}
+	}
+	return DefaultMaxDepth
+}
+
+// SetPathSyntax sets the syntax of keys used by "var"/"missing"/"missing_some". See PathSyntax.
+// The zero value means the same as parent's (or DotPath if no parent).
+func (jl *JSONLogic) SetPathSyntax(syntax PathSyntax) {
+	jl.pathSyntax = syntax
+}
+
+// PathSyntax returns the syntax of keys used by "var"/"missing"/"missing_some". See SetPathSyntax.
+func (jl *JSONLogic) PathSyntax() PathSyntax {
+	for inst := jl; inst != nil; inst = inst.parent {
+		if inst.pathSyntax != 0 {
+			return inst.pathSyntax
+		}
+	}
+	return DotPath
+}
+
+// SetClock sets the clock used by time related operations (e.g. "now" in ext package), useful to freeze
+// time in tests. nil means the same as parent's (or time.Now if no parent).
+func (jl *JSONLogic) SetClock(clock Clock) {
+	jl.clock = clock
+}
+
+// Clock returns the clock used by time related operations. See SetClock.
+func (jl *JSONLogic) Clock() Clock {
+	for inst := jl; inst != nil; inst = inst.parent {
+		if inst.clock != nil {
+			return inst.clock
+		}
+	}
+	return time.Now
+}
+
+// Now returns the current time from the clock (see JSONLogic.SetClock) of the JSONLogic instance evaluating
+// logic. The clock is read at most once in an Apply, so the result is the same during the whole evaluation.
+// Useful in operation implementation.
+func Now(apply Applier) time.Time {
+	ev := currentEvaluation(apply)
+	if ev == nil {
+		return time.Now()
+	}
+	if ev.now == nil {
+		now := ev.clock()
+		ev.now = &now
+	}
+	return *ev.now
 }
 
 // Clone is equivalent to DefaultJSONLogic.Clone.
@@ -156,11 +382,18 @@ func Clone() *JSONLogic {
 // Clone clones a JSONLogic instance.
 func (jl *JSONLogic) Clone() *JSONLogic {
 	ret := &JSONLogic{
-		parent: jl.parent,
-		ops:    make(map[string]Operation),
+		parent:     jl.parent,
+		ops:        make(map[string]Operation),
+		validators: make(map[string]ParamsValidator),
+		maxDepth:   jl.maxDepth,
+		pathSyntax: jl.pathSyntax,
+		clock:      jl.clock,
 	}
 	for k, v := range jl.ops {
 		ret.ops[k] = v
 	}
+	for k, v := range jl.validators {
+		ret.validators[k] = v
+	}
 	return ret
 }
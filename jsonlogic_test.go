@@ -1,7 +1,11 @@
 package jsonlogic
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -44,3 +48,161 @@ func TestNewInherit(t *testing.T) {
 	}
 
 }
+
+func TestClock(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	frozen := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	parent := NewEmpty()
+	AddOpVar(parent)
+	parent.AddOperation("now", func(apply Applier, params []interface{}, data interface{}) (interface{}, error) {
+		return float64(Now(apply).Unix()), nil
+	})
+	parent.AddOperation("pair", func(apply Applier, params []interface{}, data interface{}) (interface{}, error) {
+		return ApplyParams(apply, params, data)
+	})
+	parent.SetClock(func() time.Time {
+		calls++
+		return frozen.Add(time.Duration(calls) * time.Second)
+	})
+
+	child := NewInherit(parent)
+	logic := map[string]interface{}{
+		"pair": []interface{}{
+			map[string]interface{}{"now": []interface{}{}},
+			map[string]interface{}{"now": []interface{}{}},
+		},
+	}
+
+	// The clock is read once per Apply.
+	res, err := child.Apply(logic, nil)
+	assert.NoError(err)
+	assert.Equal([]interface{}{float64(frozen.Unix() + 1), float64(frozen.Unix() + 1)}, res)
+	assert.Equal(1, calls)
+
+	res, err = child.Clone().Apply(logic, nil)
+	assert.NoError(err)
+	assert.Equal([]interface{}{float64(frozen.Unix() + 2), float64(frozen.Unix() + 2)}, res)
+
+	// Not read if not used.
+	_, err = child.Apply(map[string]interface{}{"var": "a"}, nil)
+	assert.NoError(err)
+	assert.Equal(2, calls)
+
+	child.SetClock(func() time.Time { return frozen })
+	res, err = child.Apply(map[string]interface{}{"now": nil}, nil)
+	assert.NoError(err)
+	assert.Equal(float64(frozen.Unix()), res)
+
+	parent.SetClock(nil)
+	assert.NotNil(parent.Clock())
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	parent := NewEmpty()
+	AddOpVar(parent)
+	AddOpIf(parent)
+	child := NewInherit(parent)
+	AddOpCat(child)
+	AddOpVar(child)
+	AddOpLet(child)
+	AddOpVal(child)
+	AddOpDef(child)
+	AddOpCall(child)
+
+	assert.True(child.HasOperation("if"))
+	assert.True(child.HasOperation("cat"))
+	assert.False(parent.HasOperation("cat"))
+	assert.Equal([]string{"?:", "call", "cat", "def", "if", "let", "val", "var"}, child.Operations())
+	assert.Equal([]string{}, NewEmpty().Operations())
+
+	for _, tc := range []struct {
+		Logic string
+		Err   bool
+	}{
+		{`1`, false},
+		{`[1,{"var":"a"}]`, false},
+		{`{"if":[{"var":"a"},{"cat":["x",{"var":"b"}]},null]}`, false},
+		{`{"a":1,"b":{"unknown":1}}`, false},
+		{`{"cat":{"var":"a"}}`, false},
+		{`{"unknown":1}`, true},
+		{`[1,{"unknown":1}]`, true},
+		{`{"if":[{"var":"a"},{"cat":["x",{"unknown":[]}]},null]}`, true},
+		// Binding objects of "let" and function definitions of "def" are not logic.
+		{`{"let":[{"total":{"cat":[{"var":"a"},"x"]}},{"val":"total"}]}`, false},
+		{`{"let":[{"total":{"unknown":1}},{"val":"total"}]}`, true},
+		{`{"let":[{"total":1},{"unknown":1}]}`, true},
+		{`{"let":[{"var":"a"},1]}`, false},
+		{`{"let":[[],1]}`, true},
+		{`{"let":{}}`, true},
+		{`{"def":[{"f":{"body":1}},{"call":"f"}]}`, false},
+		{`{"def":[{"f":{"params":["x"],"body":{"cat":[{"val":"x"},"!"]}}},{"call":["f","a"]}]}`, false},
+		{`{"def":[{"f":{"body":{"unknown":1}}},{"call":"f"}]}`, true},
+		{`{"def":[{"f":{"body":1}},{"unknown":1}]}`, true},
+		{`{"def":[{"f":{"params":["x"]}},1]}`, true},
+		{`{"def":[{"":{"body":1}},1]}`, true},
+		{`{"def":[1,1]}`, true},
+	} {
+		var logic interface{}
+		assert.NoError(json.Unmarshal([]byte(tc.Logic), &logic))
+		err := child.Validate(logic)
+		if tc.Err {
+			assert.Error(err, tc.Logic)
+		} else {
+			assert.NoError(err, tc.Logic)
+		}
+	}
+	assert.Error(parent.Validate(map[string]interface{}{"cat": []interface{}{}}))
+
+	// The validator is removed by AddOperation of the same name.
+	letLogic := map[string]interface{}{"let": []interface{}{map[string]interface{}{"x": 1.0}, 1.0}}
+	assert.NoError(child.Validate(letLogic))
+	grandchild := NewInherit(child)
+	grandchild.AddOperation("let", opLet)
+	assert.Error(grandchild.Validate(letLogic))
+	grandchild.SetParamsValidator("let", validateLet)
+	assert.NoError(grandchild.Validate(letLogic))
+	assert.NoError(grandchild.Clone().Validate(letLogic))
+}
+
+func TestApplyContext(t *testing.T) {
+	assert := assert.New(t)
+
+	jl := New()
+	AddOpTry(jl)
+	count := 0
+	jl.AddOperation("tick", func(apply Applier, params []interface{}, data interface{}) (interface{}, error) {
+		count++
+		time.Sleep(time.Millisecond)
+		return nil, nil
+	})
+	items := make([]interface{}, 1000)
+	data := map[string]interface{}{"items": items}
+	var logic interface{}
+	assert.NoError(json.Unmarshal([]byte(`{"map":[{"var":"items"},{"tick":[]}]}`), &logic))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := jl.ApplyContext(ctx, logic, data)
+	assert.True(errors.Is(err, context.Canceled))
+	assert.Equal(0, count)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = jl.ApplyContext(ctx, logic, data)
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+	assert.True(count > 0 && count < len(items), count)
+
+	// Not caught by "try".
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = jl.ApplyContext(ctx, map[string]interface{}{"try": []interface{}{logic, 1.0}}, data)
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+
+	res, err := jl.ApplyContext(context.Background(), map[string]interface{}{"+": []interface{}{1.0, 2.0}}, nil)
+	assert.NoError(err)
+	assert.Equal(3.0, res)
+}
@@ -0,0 +1,545 @@
+package jsonlogic
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PathSyntax is the syntax of keys used by "var"/"missing"/"missing_some" (and "exists") to address values
+// in data. For all syntaxes, a null or "" key addresses the whole data.
+type PathSyntax int
+
+const (
+	// DotPath is the json-logic-js compatible syntax and the default one: a key is converted to string
+	// then split by "." into keys of objects or indices of arrays, e.g. "a.b.0".
+	DotPath PathSyntax = iota + 1
+	// EscapedDotPath is the same as DotPath except that:
+	//   - "\." stands for a literal "." and "\\" for a literal "\" in a string key, e.g. "user\.name"
+	//     addresses the key "user.name".
+	//   - Numeric keys are not split, e.g. 0.1 addresses the key "0.1".
+	//   - For arrays, negative index counts from the end, e.g. "items.-1" is the last item.
+	//   - For arrays, "start:end" gets a sub-array from start (inclusive) to end (exclusive), either can be
+	//     omitted and negative value counts from the end like "substr", e.g. "items.1:3", "items.-2:".
+	// These array extensions are not supported by json-logic-js, use DotPath for strict compatibility.
+	EscapedDotPath
+	// JSONPointer is RFC 6901 JSON Pointer for string keys, e.g. "/a/b/0". Numeric keys are not split.
+	JSONPointer
+	// JSONPath is a subset of JSONPath for string keys, numeric keys are not split. Supported:
+	//   - $: the root, must be the first.
+	//   - .name or ['name'] or ["name"]: key of object.
+	//   - [n]: index of array, negative index counts from the end.
+	//   - [start:end]: sub-array, the same as EscapedDotPath.
+	//   - .* or [*]: all items of array or values of object (ordered by keys).
+	//   - [?(@.a.b)]: items/values having path "a.b".
+	//   - [?(@.a.b op literal)]: items/values whose path "a.b" compared with literal (string/number/bool/null)
+	//     is true. op is one of "==" (strict), "!=" (strict), "<", "<=", ">" and ">=". "@" itself can also be compared.
+	// If the path contains "*" or filters, the result is an array of all matched values, and it is treated as
+	// not found if nothing matches.
+	JSONPath
+)
+
+// String implements fmt.Stringer.
+func (syntax PathSyntax) String() string {
+	switch syntax {
+	case DotPath:
+		return "DotPath"
+	case EscapedDotPath:
+		return "EscapedDotPath"
+	case JSONPointer:
+		return "JSONPointer"
+	case JSONPath:
+		return "JSONPath"
+	default:
+		return fmt.Sprintf("PathSyntax(%d)", int(syntax))
+	}
+}
+
+// pathSyntaxOf returns the path syntax of the evaluation which the applier belongs to.
+func pathSyntaxOf(apply Applier) PathSyntax {
+	if ev := currentEvaluation(apply); ev != nil && ev.pathSyntax != 0 {
+		return ev.pathSyntax
+	}
+	return DotPath
+}
+
+type pathSegKind int
+
+const (
+	segKey pathSegKind = iota
+	segWildcard
+	segFilter
+)
+
+// pathSeg is a segment of a parsed path.
+type pathSeg struct {
+	kind pathSegKind
+	key  string
+	// ext enables negative index and slice for arrays. See getSegmentExt.
+	ext    bool
+	filter *pathFilter
+}
+
+// get gets the value addressed by a key segment from obj.
+func (seg *pathSeg) get(obj interface{}) (interface{}, bool) {
+	if seg.ext {
+		return getSegmentExt(obj, seg.key)
+	}
+	return getSegment(obj, seg.key)
+}
+
+// pathFilter is a JSONPath filter expression: [?(@.path op value)].
+type pathFilter struct {
+	// path contains key segments only.
+	path  []pathSeg
+	op    string // empty for existence test
+	value interface{}
+}
+
+func (f *pathFilter) match(obj interface{}) bool {
+	v := obj
+	for i := range f.path {
+		var found bool
+		v, found = f.path[i].get(v)
+		if !found {
+			return false
+		}
+	}
+	if f.op == "" {
+		return true
+	}
+
+	var symbol CompSymbol
+	switch f.op {
+	case "==":
+		symbol = EQ
+	case "!=":
+		symbol = NE
+	default:
+		symbol = CompSymbol(f.op)
+	}
+	// NOTE: Values can't be compared (e.g. non-primitives) are just not matched.
+	r, err := CompareValues(symbol, v, f.value)
+	return err == nil && r
+}
+
+func keySegs(keys []string, ext bool) []pathSeg {
+	segs := make([]pathSeg, 0, len(keys))
+	for _, key := range keys {
+		segs = append(segs, pathSeg{kind: segKey, key: key, ext: ext})
+	}
+	return segs
+}
+
+// parsePath parses a non-empty key into segments according to syntax.
+func parsePath(syntax PathSyntax, key string, isString bool) ([]pathSeg, error) {
+	if syntax == DotPath {
+		return keySegs(strings.Split(key, "."), false), nil
+	}
+	if !isString {
+		return keySegs([]string{key}, syntax != JSONPointer), nil
+	}
+	switch syntax {
+	case EscapedDotPath:
+		return parseEscapedDotPath(key)
+	case JSONPointer:
+		return parseJSONPointer(key)
+	case JSONPath:
+		return parseJSONPath(key)
+	default:
+		return nil, fmt.Errorf("unknown path syntax %s", syntax)
+	}
+}
+
+func parseEscapedDotPath(key string) ([]pathSeg, error) {
+	keys := []string{}
+	b := strings.Builder{}
+	escaping := false
+	for _, r := range key {
+		if escaping {
+			if r != '.' && r != '\\' {
+				return nil, fmt.Errorf("invalid path %q: bad escape %q", key, "\\"+string(r))
+			}
+			b.WriteRune(r)
+			escaping = false
+			continue
+		}
+		switch r {
+		case '\\':
+			escaping = true
+		case '.':
+			keys = append(keys, b.String())
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if escaping {
+		return nil, fmt.Errorf("invalid path %q: incomplete escape", key)
+	}
+	keys = append(keys, b.String())
+	return keySegs(keys, true), nil
+}
+
+func parseJSONPointer(key string) ([]pathSeg, error) {
+	if key[0] != '/' {
+		return nil, fmt.Errorf("invalid path %q: json pointer must start with '/'", key)
+	}
+	keys := strings.Split(key[1:], "/")
+	for i, k := range keys {
+		for j := 0; j < len(k); j++ {
+			if k[j] == '~' && (j+1 >= len(k) || (k[j+1] != '0' && k[j+1] != '1')) {
+				return nil, fmt.Errorf("invalid path %q: bad escape in %q", key, k)
+			}
+		}
+		keys[i] = strings.Replace(strings.Replace(k, "~1", "/", -1), "~0", "~", -1)
+	}
+	return keySegs(keys, false), nil
+}
+
+// jsonPathParser is a simple recursive descent parser for JSONPath subset.
+type jsonPathParser struct {
+	src string
+	pos int
+}
+
+func parseJSONPath(key string) ([]pathSeg, error) {
+	p := &jsonPathParser{src: key}
+	segs, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %s", key, err.Error())
+	}
+	return segs, nil
+}
+
+func (p *jsonPathParser) parse() ([]pathSeg, error) {
+	if !p.consume("$") {
+		return nil, fmt.Errorf("json path must start with '$'")
+	}
+	segs := []pathSeg{}
+	for p.pos < len(p.src) {
+		seg, err := p.parseSeg(true)
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, seg)
+	}
+	return segs, nil
+}
+
+// parseSeg parses a segment. Wildcards and filters are not allowed if complex is false.
+func (p *jsonPathParser) parseSeg(complex bool) (pathSeg, error) {
+	switch {
+	case p.consume(".."):
+		return pathSeg{}, fmt.Errorf("recursive descent is not supported")
+
+	case p.consume("."):
+		if complex && p.consume("*") {
+			return pathSeg{kind: segWildcard}, nil
+		}
+		terminators := ".["
+		if !complex {
+			// Inside filter.
+			terminators = ".[ )=!<>"
+		}
+		start := p.pos
+		for p.pos < len(p.src) && !strings.ContainsRune(terminators, rune(p.src[p.pos])) {
+			p.pos++
+		}
+		if p.pos == start {
+			return pathSeg{}, fmt.Errorf("expect name at %d", start)
+		}
+		return pathSeg{kind: segKey, key: p.src[start:p.pos]}, nil
+
+	case p.consume("["):
+		var seg pathSeg
+		switch {
+		case complex && p.consume("*"):
+			seg = pathSeg{kind: segWildcard}
+		case complex && p.consume("?("):
+			filter, err := p.parseFilter()
+			if err != nil {
+				return pathSeg{}, err
+			}
+			seg = pathSeg{kind: segFilter, filter: filter}
+		case p.peekQuote():
+			s, err := p.parseQuoted()
+			if err != nil {
+				return pathSeg{}, err
+			}
+			seg = pathSeg{kind: segKey, key: s}
+		default:
+			start := p.pos
+			for p.pos < len(p.src) && strings.ContainsRune("-:0123456789", rune(p.src[p.pos])) {
+				p.pos++
+			}
+			if p.pos == start {
+				return pathSeg{}, fmt.Errorf("expect index at %d", start)
+			}
+			key := p.src[start:p.pos]
+			if !isIndexKey(key) {
+				return pathSeg{}, fmt.Errorf("invalid index or slice %q at %d", key, start)
+			}
+			seg = pathSeg{kind: segKey, key: key, ext: true}
+		}
+		if !p.consume("]") {
+			return pathSeg{}, fmt.Errorf("expect ']' at %d", p.pos)
+		}
+		return seg, nil
+
+	default:
+		return pathSeg{}, fmt.Errorf("unexpected %q at %d", p.src[p.pos], p.pos)
+	}
+}
+
+func (p *jsonPathParser) parseFilter() (*pathFilter, error) {
+	p.skipSpaces()
+	if !p.consume("@") {
+		return nil, fmt.Errorf("expect '@' at %d", p.pos)
+	}
+	filter := &pathFilter{}
+	for p.pos < len(p.src) && (p.src[p.pos] == '.' || p.src[p.pos] == '[') {
+		seg, err := p.parseSeg(false)
+		if err != nil {
+			return nil, err
+		}
+		filter.path = append(filter.path, seg)
+	}
+
+	p.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			filter.op = op
+			break
+		}
+	}
+	if filter.op != "" {
+		p.skipSpaces()
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		filter.value = value
+		p.skipSpaces()
+	}
+
+	if !p.consume(")") {
+		return nil, fmt.Errorf("expect ')' at %d", p.pos)
+	}
+	return filter, nil
+}
+
+func (p *jsonPathParser) parseLiteral() (interface{}, error) {
+	if p.peekQuote() {
+		return p.parseQuoted()
+	}
+	for _, lit := range []struct {
+		s string
+		v interface{}
+	}{
+		{"true", true},
+		{"false", false},
+		{"null", nil},
+	} {
+		if p.consume(lit.s) {
+			return lit.v, nil
+		}
+	}
+	start := p.pos
+	for p.pos < len(p.src) && strings.ContainsRune("+-.0123456789eE", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	f, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return nil, fmt.Errorf("expect literal at %d", start)
+	}
+	return f, nil
+}
+
+func (p *jsonPathParser) peekQuote() bool {
+	return p.pos < len(p.src) && (p.src[p.pos] == '\'' || p.src[p.pos] == '"')
+}
+
+func (p *jsonPathParser) parseQuoted() (string, error) {
+	quote := p.src[p.pos]
+	start := p.pos
+	p.pos++
+	b := strings.Builder{}
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case quote:
+			return b.String(), nil
+		case '\\':
+			if p.pos >= len(p.src) {
+				return "", fmt.Errorf("incomplete escape at %d", p.pos)
+			}
+			b.WriteByte(p.src[p.pos])
+			p.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string at %d", start)
+}
+
+func (p *jsonPathParser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *jsonPathParser) skipSpaces() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// walkPath walks data along segments. If there is any wildcard or filter segment, the result is an array
+// of all matched values.
+func walkPath(data interface{}, segs []pathSeg) (interface{}, bool) {
+	definite := true
+	for _, seg := range segs {
+		if seg.kind != segKey {
+			definite = false
+			break
+		}
+	}
+
+	if definite {
+		res := data
+		for _, seg := range segs {
+			var found bool
+			res, found = seg.get(res)
+			if !found {
+				return nil, false
+			}
+		}
+		return res, true
+	}
+
+	values := []interface{}{data}
+	for _, seg := range segs {
+		next := []interface{}{}
+		for _, v := range values {
+			switch seg.kind {
+			case segKey:
+				if r, found := seg.get(v); found {
+					next = append(next, r)
+				}
+			case segWildcard:
+				next = append(next, children(v)...)
+			case segFilter:
+				for _, child := range children(v) {
+					if seg.filter.match(child) {
+						next = append(next, child)
+					}
+				}
+			}
+		}
+		values = next
+	}
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values, true
+}
+
+// children returns items of array or values of object ordered by keys.
+func children(obj interface{}) []interface{} {
+	switch o := obj.(type) {
+	case []interface{}:
+		return o
+	case map[string]interface{}:
+		keys := make([]string, 0, len(o))
+		for key := range o {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		ret := make([]interface{}, 0, len(o))
+		for _, key := range keys {
+			ret = append(ret, o[key])
+		}
+		return ret
+	}
+	return nil
+}
+
+// isIndexKey returns true if key is an integer index or a "start:end" slice with optional integer bounds.
+func isIndexKey(key string) bool {
+	bounds := strings.Split(key, ":")
+	if len(bounds) > 2 {
+		return false
+	}
+	for _, bound := range bounds {
+		if bound == "" && len(bounds) == 2 {
+			continue
+		}
+		if _, err := strconv.Atoi(bound); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// getSegmentExt is the same as getSegment except for arrays: negative index counts from the end and
+// "start:end" gets a sub-array. Keys not satisfying isIndexKey (only possible in escaped dot paths since
+// JSONPath rejects them when parsing) are not found in arrays.
+func getSegmentExt(obj interface{}, key string) (interface{}, bool) {
+	arr, ok := obj.([]interface{})
+	if !ok {
+		return getSegment(obj, key)
+	}
+
+	if i := strings.IndexByte(key, ':'); i >= 0 {
+		start, ok := sliceBound(key[:i], 0, len(arr))
+		if !ok {
+			return nil, false
+		}
+		end, ok := sliceBound(key[i+1:], len(arr), len(arr))
+		if !ok {
+			return nil, false
+		}
+		if start >= end {
+			return []interface{}{}, true
+		}
+		return append([]interface{}{}, arr[start:end]...), true
+	}
+
+	i, err := strconv.Atoi(key)
+	if err != nil {
+		return nil, false
+	}
+	if i < 0 {
+		i += len(arr)
+	}
+	if i < 0 || i >= len(arr) {
+		return nil, false
+	}
+	return arr[i], true
+}
+
+// sliceBound converts a bound of slice to an index in [0, n].
+func sliceBound(s string, def, n int) (int, bool) {
+	if s == "" {
+		return def, true
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+	if i < 0 {
+		i += n
+		if i < 0 {
+			i = 0
+		}
+	}
+	if i > n {
+		i = n
+	}
+	return i, true
+}
@@ -0,0 +1,171 @@
+package jsonlogic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPathTestJSONLogic(syntax PathSyntax) *JSONLogic {
+	jl := NewEmpty()
+	jl.SetPathSyntax(syntax)
+	AddOpVar(jl)
+	AddOpMissing(jl)
+	AddOpMissingSome(jl)
+	AddOpExists(jl)
+	return jl
+}
+
+func TestPathSyntax(t *testing.T) {
+	assert := assert.New(t)
+
+	parent := NewEmpty()
+	assert.Equal(DotPath, parent.PathSyntax())
+	child := NewInherit(parent)
+	parent.SetPathSyntax(JSONPointer)
+	assert.Equal(JSONPointer, child.PathSyntax())
+	child.SetPathSyntax(JSONPath)
+	assert.Equal(JSONPath, child.PathSyntax())
+	assert.Equal(JSONPath, child.Clone().PathSyntax())
+	assert.Equal("JSONPath", JSONPath.String())
+	assert.Equal("PathSyntax(0)", PathSyntax(0).String())
+}
+
+func TestDotPath(t *testing.T) {
+	assert := assert.New(t)
+	TestCases{
+		// No negative index or slice in strict mode.
+		{Logic: `{"var":"items.-1"}`, Data: `{"items":[1,2]}`, Result: nil},
+		{Logic: `{"var":"items.0:1"}`, Data: `{"items":[1,2]}`, Result: nil},
+		{Logic: `{"var":"a\\.b"}`, Data: `{"a\\":{"b":1}}`, Result: float64(1)},
+	}.Run(assert, newPathTestJSONLogic(DotPath))
+}
+
+func TestEscapedDotPath(t *testing.T) {
+	assert := assert.New(t)
+	TestCases{
+		{Logic: `{"var":"a.b"}`, Data: `{"a":{"b":1},"a.b":2}`, Result: float64(1)},
+		{Logic: `{"var":"a\\.b"}`, Data: `{"a":{"b":1},"a.b":2}`, Result: float64(2)},
+		{Logic: `{"var":"user\\.name.first"}`, Data: `{"user.name":{"first":"Bob"}}`, Result: "Bob"},
+		{Logic: `{"var":"example\\.com.port"}`, Data: `{"example.com":{"port":80}}`, Result: float64(80)},
+		{Logic: `{"var":"a\\\\b"}`, Data: `{"a\\b":1}`, Result: float64(1)},
+		{Logic: `{"var":"a.1"}`, Data: `{"a":[0,1]}`, Result: float64(1)},
+		// Numeric keys are not split.
+		{Logic: `{"var":0.1}`, Data: `{"0.1":1}`, Result: float64(1)},
+		{Logic: `{"var":1}`, Data: `["a","b"]`, Result: "b"},
+		// Negative index.
+		{Logic: `{"var":"items.-1.price"}`, Data: `{"items":[{"price":1},{"price":2}]}`, Result: float64(2)},
+		{Logic: `{"var":"items.-2.price"}`, Data: `{"items":[{"price":1},{"price":2}]}`, Result: float64(1)},
+		{Logic: `{"var":"items.-3.price"}`, Data: `{"items":[{"price":1},{"price":2}]}`, Result: nil},
+		{Logic: `{"var":-1}`, Data: `["a","b"]`, Result: "b"},
+		{Logic: `{"var":"a.-1"}`, Data: `{"a":{"-1":"x"}}`, Result: "x"},
+		// Slice.
+		{Logic: `{"var":"xs.1:3"}`, Data: `{"xs":[0,1,2,3]}`, Result: []interface{}{float64(1), float64(2)}},
+		{Logic: `{"var":"xs.:2"}`, Data: `{"xs":[0,1,2,3]}`, Result: []interface{}{float64(0), float64(1)}},
+		{Logic: `{"var":"xs.2:"}`, Data: `{"xs":[0,1,2,3]}`, Result: []interface{}{float64(2), float64(3)}},
+		{Logic: `{"var":"xs.-2:"}`, Data: `{"xs":[0,1,2,3]}`, Result: []interface{}{float64(2), float64(3)}},
+		{Logic: `{"var":"xs.:-1"}`, Data: `{"xs":[0,1,2,3]}`, Result: []interface{}{float64(0), float64(1), float64(2)}},
+		{Logic: `{"var":"xs.:"}`, Data: `{"xs":[0,1]}`, Result: []interface{}{float64(0), float64(1)}},
+		{Logic: `{"var":"xs.-10:10"}`, Data: `{"xs":[0,1]}`, Result: []interface{}{float64(0), float64(1)}},
+		{Logic: `{"var":"xs.3:1"}`, Data: `{"xs":[0,1,2,3]}`, Result: []interface{}{}},
+		{Logic: `{"var":"xs.a:1"}`, Data: `{"xs":[0,1,2,3]}`, Result: nil},
+		{Logic: `{"var":"xs.1:2:3"}`, Data: `{"xs":[0,1,2,3]}`, Result: nil},
+		{Logic: `{"var":"xs.1:3"}`, Data: `{"xs":{"1:3":"k"}}`, Result: "k"},
+		// Missing.
+		{Logic: `{"missing":["a\\.b","c"]}`, Data: `{"a.b":1}`, Result: []interface{}{"c"}},
+		{Logic: `{"missing_some":[1,["a\\.b","c"]]}`, Data: `{"a.b":1}`, Result: []interface{}{}},
+		{Logic: `{"exists":"a\\.b"}`, Data: `{"a.b":null}`, Result: true},
+		// Err.
+		{Logic: `{"var":"a\\b"}`, Data: `{}`, Err: true},
+		{Logic: `{"var":"a\\"}`, Data: `{}`, Err: true},
+		{Logic: `{"missing":"a\\"}`, Data: `{}`, Err: true},
+	}.Run(assert, newPathTestJSONLogic(EscapedDotPath))
+}
+
+func TestJSONPointer(t *testing.T) {
+	assert := assert.New(t)
+	TestCases{
+		{Logic: `{"var":""}`, Data: `{"a":1}`, Result: map[string]interface{}{"a": float64(1)}},
+		{Logic: `{"var":"/a"}`, Data: `{"a":1}`, Result: float64(1)},
+		{Logic: `{"var":"/a/b/1"}`, Data: `{"a":{"b":[1,2]}}`, Result: float64(2)},
+		{Logic: `{"var":"/user.name"}`, Data: `{"user.name":"Bob"}`, Result: "Bob"},
+		{Logic: `{"var":"/a~1b"}`, Data: `{"a/b":1}`, Result: float64(1)},
+		{Logic: `{"var":"/a~0b"}`, Data: `{"a~b":1}`, Result: float64(1)},
+		{Logic: `{"var":"/a~01"}`, Data: `{"a~1":1}`, Result: float64(1)},
+		{Logic: `{"var":"/"}`, Data: `{"":1}`, Result: float64(1)},
+		{Logic: `{"var":["/x","def"]}`, Data: `{}`, Result: "def"},
+		{Logic: `{"var":1}`, Data: `["a","b"]`, Result: "b"},
+		// Missing.
+		{Logic: `{"missing":["/a/b","/c"]}`, Data: `{"a":{"b":1}}`, Result: []interface{}{"/c"}},
+		{Logic: `{"missing_some":[2,["/a/b","/c"]]}`, Data: `{"a":{"b":1}}`, Result: []interface{}{"/c"}},
+		// Err.
+		{Logic: `{"var":"a"}`, Data: `{}`, Err: true},
+		{Logic: `{"var":"/a~2"}`, Data: `{}`, Err: true},
+		{Logic: `{"var":"/a~"}`, Data: `{}`, Err: true},
+	}.Run(assert, newPathTestJSONLogic(JSONPointer))
+}
+
+func TestJSONPath(t *testing.T) {
+	assert := assert.New(t)
+	data := `{
+		"store": {
+			"name": "s1",
+			"example.com": true,
+			"books": [
+				{"title": "a", "price": 8, "isbn": "x"},
+				{"title": "b", "price": 12},
+				{"title": "c", "price": 20, "tags": ["new"]}
+			]
+		},
+		"limit": 10
+	}`
+	TestCases{
+		{Logic: `{"var":"$"}`, Data: `{"a":1}`, Result: map[string]interface{}{"a": float64(1)}},
+		{Logic: `{"var":"$.store.name"}`, Data: data, Result: "s1"},
+		{Logic: `{"var":"$['store']['example.com']"}`, Data: data, Result: true},
+		{Logic: `{"var":"$[\"store\"].books[1].title"}`, Data: data, Result: "b"},
+		{Logic: `{"var":"$.store.books[3]"}`, Data: data, Result: nil},
+		{Logic: `{"var":"$.store.books[-1].title"}`, Data: data, Result: "c"},
+		{Logic: `{"var":"$.store.books[0:2]"}`, Data: `{"store":{"books":[1,2,3]}}`, Result: []interface{}{float64(1), float64(2)}},
+		{Logic: `{"var":"$.store.books[-2:]"}`, Data: `{"store":{"books":[1,2,3]}}`, Result: []interface{}{float64(2), float64(3)}},
+		{Logic: `{"var":"$.store.books[*].title"}`, Data: data, Result: []interface{}{"a", "b", "c"}},
+		{Logic: `{"var":"$.store.books.*.price"}`, Data: data, Result: []interface{}{float64(8), float64(12), float64(20)}},
+		{Logic: `{"var":"$.store.books[*].isbn"}`, Data: data, Result: []interface{}{"x"}},
+		{Logic: `{"var":"$.*"}`, Data: `{"b":2,"a":1}`, Result: []interface{}{float64(1), float64(2)}},
+		// Filters.
+		{Logic: `{"var":"$.store.books[?(@.price > 10)].title"}`, Data: data, Result: []interface{}{"b", "c"}},
+		{Logic: `{"var":"$.store.books[?(@.price<=8)].title"}`, Data: data, Result: []interface{}{"a"}},
+		{Logic: `{"var":"$.store.books[?(@.title == 'c')].price"}`, Data: data, Result: []interface{}{float64(20)}},
+		{Logic: `{"var":"$.store.books[?(@.title != \"c\")].price"}`, Data: data, Result: []interface{}{float64(8), float64(12)}},
+		{Logic: `{"var":"$.store.books[?(@.isbn)].title"}`, Data: data, Result: []interface{}{"a"}},
+		{Logic: `{"var":"$.store.books[?(@.tags[0] == 'new')].title"}`, Data: data, Result: []interface{}{"c"}},
+		{Logic: `{"var":"$.store.books[?(@['price'] >= 12)].title"}`, Data: data, Result: []interface{}{"b", "c"}},
+		{Logic: `{"var":"$.a[?(@.t[-1]==2)].n"}`, Data: `{"a":[{"n":1,"t":[1,2]},{"n":2,"t":[2,3]}]}`, Result: []interface{}{float64(1)}},
+		{Logic: `{"var":"$.a[?(@.t[0:1])].n"}`, Data: `{"a":[{"n":1,"t":[]},{"n":2},{"n":3,"t":{}}]}`, Result: []interface{}{float64(1)}},
+		{Logic: `{"var":"$.store.books[*].price[?(@ > 10)]"}`, Data: data, Result: nil},
+		{Logic: `{"var":"$.xs[?(@ > 1)]"}`, Data: `{"xs":[1,2,3]}`, Result: []interface{}{float64(2), float64(3)}},
+		{Logic: `{"var":"$.xs[?(@ == null)]"}`, Data: `{"xs":[1,null]}`, Result: []interface{}{nil}},
+		{Logic: `{"var":"$.xs[?(@ == true)]"}`, Data: `{"xs":[true,false,[]]}`, Result: []interface{}{true}},
+		// Nothing matches is treated as not found.
+		{Logic: `{"var":["$.store.books[?(@.price > 100)]","none"]}`, Data: data, Result: "none"},
+		// Missing.
+		{Logic: `{"missing":["$.store.name","$.store.owner"]}`, Data: data, Result: []interface{}{"$.store.owner"}},
+		{Logic: `{"missing":"$.store.books[?(@.price > 100)]"}`, Data: data, Result: []interface{}{"$.store.books[?(@.price > 100)]"}},
+		{Logic: `{"exists":"$.store.books[?(@.price > 10)]"}`, Data: data, Result: true},
+		// Err.
+		{Logic: `{"var":"store"}`, Data: data, Err: true},
+		{Logic: `{"var":"$..price"}`, Data: data, Err: true},
+		{Logic: `{"var":"$.store["}`, Data: data, Err: true},
+		{Logic: `{"var":"$.store[x]"}`, Data: data, Err: true},
+		{Logic: `{"var":"$.store.books[1:2:3]"}`, Data: data, Err: true},
+		{Logic: `{"var":"$.store.books[--1]"}`, Data: data, Err: true},
+		{Logic: `{"var":"$.store.books[1-]"}`, Data: data, Err: true},
+		{Logic: `{"var":"$.store.books[?(@.tags[1:2:3])]"}`, Data: data, Err: true},
+		{Logic: `{"var":"$.store.books[:]"}`, Data: `{"store":{"books":[1,2]}}`, Result: []interface{}{float64(1), float64(2)}},
+		{Logic: `{"var":"$.store['x"}`, Data: data, Err: true},
+		{Logic: `{"var":"$.store."}`, Data: data, Err: true},
+		{Logic: `{"var":"$.books[?(@.price > )]"}`, Data: data, Err: true},
+		{Logic: `{"var":"$.books[?(price > 1)]"}`, Data: data, Err: true},
+		{Logic: `{"var":"$.books[?(@.price > 1]"}`, Data: data, Err: true},
+	}.Run(assert, newPathTestJSONLogic(JSONPath))
+}
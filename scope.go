@@ -0,0 +1,295 @@
+package jsonlogic
+
+import (
+	"fmt"
+)
+
+// scope is a frame of the scope stack maintained during evaluation.
+type scope struct {
+	parent *scope
+	// vars are bindings (e.g. introduced by "let") visible in this scope and its descendants.
+	vars map[string]interface{}
+	// funcs are user-defined functions (introduced by "def") visible in this scope and its descendants.
+	funcs map[string]*function
+	// hasData is true for the root scope (data passed to Apply) and iteration scopes (item of an array).
+	hasData bool
+	data    interface{}
+	// isIter is true for iteration scopes and index is the item's index in the array.
+	isIter bool
+	index  int
+}
+
+// lookupVar finds a binding by name from the innermost scope outward.
+func (sc *scope) lookupVar(name string) (interface{}, bool) {
+	for s := sc; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// lookupFunc finds a user-defined function by name from the innermost scope outward.
+func (sc *scope) lookupFunc(name string) (*function, bool) {
+	for s := sc; s != nil; s = s.parent {
+		if fn, ok := s.funcs[name]; ok {
+			return fn, true
+		}
+	}
+	return nil, false
+}
+
+// dataScope returns the level-th scope with data from the innermost scope outward, level 0 is the innermost one.
+func (sc *scope) dataScope(level int) (*scope, bool) {
+	for s := sc; s != nil; s = s.parent {
+		if !s.hasData {
+			continue
+		}
+		if level == 0 {
+			return s, true
+		}
+		level--
+	}
+	return nil, false
+}
+
+// iterScope returns the level-th iteration scope from the innermost scope outward, level 0 is the innermost one.
+func (sc *scope) iterScope(level int) (*scope, bool) {
+	for s := sc; s != nil; s = s.parent {
+		if !s.isIter {
+			continue
+		}
+		if level == 0 {
+			return s, true
+		}
+		level--
+	}
+	return nil, false
+}
+
+// scopedLogic is a logic which should be evaluated in a new scope (frame) pushed on top of current scope.
+type scopedLogic struct {
+	logic interface{}
+	frame *scope
+}
+
+// scopeQuery is a special logic which returns the current scope.
+type scopeQuery struct{}
+
+// currentScope returns the scope which the applier is evaluating in, or nil if it is not available
+// (e.g. the applier is not created by JSONLogic).
+func currentScope(apply Applier) *scope {
+	r, err := apply(scopeQuery{}, nil)
+	if err != nil {
+		return nil
+	}
+	sc, _ := r.(*scope)
+	return sc
+}
+
+// evaluationQuery is a special logic which returns the current evaluation.
+type evaluationQuery struct{}
+
+// currentEvaluation returns the evaluation which the applier belongs to, or nil if it is not available
+// (e.g. the applier is not created by JSONLogic).
+func currentEvaluation(apply Applier) *evaluation {
+	r, err := apply(evaluationQuery{}, nil)
+	if err != nil {
+		return nil
+	}
+	ev, _ := r.(*evaluation)
+	return ev
+}
+
+// applyWithVars evaluates logic against data in a new scope with extra bindings.
+func applyWithVars(apply Applier, logic, data interface{}, vars map[string]interface{}) (interface{}, error) {
+	return apply(scopedLogic{
+		logic: logic,
+		frame: &scope{vars: vars},
+	}, data)
+}
+
+// ApplyIter evaluates logic against item, the index-th element of an array, in a new iteration scope.
+// Operations iterating arrays should use it instead of calling apply(logic, item) directly, so that the logic
+// can still reach outer data and the current index. See "val" and "index".
+func ApplyIter(apply Applier, logic, item interface{}, index int) (interface{}, error) {
+	return apply(scopedLogic{
+		logic: logic,
+		frame: &scope{
+			hasData: true,
+			data:    item,
+			isIter:  true,
+			index:   index,
+		},
+	}, item)
+}
+
+// AddOpLet adds "let" operation to the JSONLogic instance. Param restriction:
+//   - Two params: the first is an object mapping names to logics and the second the body logic.
+//
+// Each logic in the object is evaluated once against current data, then the body is evaluated with
+// these values bound to the names, which can be read by "val" anywhere inside the body (including inside
+// "map"/"filter"/...). Bindings are evaluated in the outer scope so they can not reference each other,
+// nest "let" for that. Inner bindings shadow outer ones with the same name, for example:
+//   logic: {"let":[{"total":{"+":[{"var":"a"},{"var":"b"}]}},{"*":[{"val":"total"},{"val":"total"}]}]}
+//   data: {"a":1,"b":2}
+//   result will be 9
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpLet(jl *JSONLogic) {
+	jl.AddOperation("let", opLet)
+	jl.SetParamsValidator("let", validateLet)
+}
+
+func validateLet(validate func(logic interface{}) error, params []interface{}) error {
+	if len(params) != 2 {
+		return fmt.Errorf("Validate: let: expect 2 params")
+	}
+	bindings, ok := params[0].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Validate: let: expect object for param 0 but got %T", params[0])
+	}
+	for _, logic := range bindings {
+		if err := validate(logic); err != nil {
+			return err
+		}
+	}
+	return validate(params[1])
+}
+
+func opLet(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	if len(params) != 2 {
+		return nil, fmt.Errorf("let: expect 2 params")
+	}
+	// NOTE: The bindings object can't be evaluated as a whole since a single key object is treated as logic.
+	bindings, ok := params[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("let: expect object for param 0 but got %T", params[0])
+	}
+
+	vars := make(map[string]interface{}, len(bindings))
+	for name, logic := range bindings {
+		if name == "" {
+			return nil, fmt.Errorf("let: binding name must not be empty")
+		}
+		v, err := apply(logic, data)
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = v
+	}
+
+	return applyWithVars(apply, params[1], data, vars)
+}
+
+// AddOpVal adds "val" operation to the JSONLogic instance. "val" is similar to "var" except:
+//   - Each param is a single path segment, a key of object or an index of array, they are NOT split by ".".
+//   - If the first segment names a binding (see "let"), the path is resolved from the binding value
+//     instead of data.
+//   - Returns null if path not found, there is no default value.
+//   - If the first param is an array containing a single non-negative integer n, e.g. [1], then the path is
+//     resolved from the data n levels up: level 0 is current data, level 1 is the data outside the innermost
+//     iterating operation ("map"/"filter"/...), level 2 is the data outside the second innermost one and so on.
+//     Bindings are not looked up in this form.
+//
+// Examples:
+//   - {"val":[]} or {"val":null} -> whole data
+//   - {"val":"a.b"} -> data["a.b"]
+//   - {"val":["a","b"]} -> data["a"]["b"]
+//   - {"val":["arr",0]} -> data["arr"][0]
+//   - {"filter":[{"var":"orders"},{">":[{"val":"amount"},{"val":[[1],"limit"]}]}]} -> orders with amount > limit
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpVal(jl *JSONLogic) {
+	jl.AddOperation("val", opVal)
+}
+
+func opVal(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	params, err = ApplyParams(apply, params, data)
+	if err != nil {
+		return
+	}
+
+	if len(params) == 0 || (len(params) == 1 && params[0] == nil) {
+		return data, nil
+	}
+
+	res = data
+	sc := currentScope(apply)
+	if lv, ok := params[0].([]interface{}); ok {
+		level, err := toLevel(lv)
+		if err != nil {
+			return nil, fmt.Errorf("val: %s", err.Error())
+		}
+		if level > 0 {
+			s, found := sc.dataScope(level)
+			if !found {
+				return nil, fmt.Errorf("val: scope level %d out of range", level)
+			}
+			res = s.data
+		}
+		params = params[1:]
+
+	} else if name, ok := params[0].(string); ok {
+		if v, found := sc.lookupVar(name); found {
+			res = v
+			params = params[1:]
+		}
+	}
+
+	for _, seg := range params {
+		if !IsPrimitive(seg) {
+			return nil, fmt.Errorf("val: path segment must be json primitive but got %T", seg)
+		}
+		var found bool
+		res, found = getSegment(res, seg)
+		if !found {
+			return nil, nil
+		}
+	}
+	return res, nil
+}
+
+// AddOpIndex adds "index" operation to the JSONLogic instance. It returns the index of the current item in
+// an iterating operation ("map"/"filter"/...). An optional param n (non-negative integer) is accepted to get
+// the index of the n-th outer iterating operation. For example:
+//   logic: {"map":[["a","b"],{"cat":[{"index":[]},":",{"var":""}]}]}
+//   result will be ["0:a","1:b"]
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpIndex(jl *JSONLogic) {
+	jl.AddOperation("index", opIndex)
+}
+
+func opIndex(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	params, err = ApplyParams(apply, params, data)
+	if err != nil {
+		return
+	}
+
+	level := 0
+	if len(params) > 0 && params[0] != nil {
+		level, err = toLevel(params[:1])
+		if err != nil {
+			return nil, fmt.Errorf("index: %s", err.Error())
+		}
+	}
+
+	s, found := currentScope(apply).iterScope(level)
+	if !found {
+		return nil, fmt.Errorf("index: not in iteration of level %d", level)
+	}
+	return float64(s.index), nil
+}
+
+// toLevel converts [n] to a scope level.
+func toLevel(lv []interface{}) (int, error) {
+	if len(lv) != 1 {
+		return 0, fmt.Errorf("expect exactly one scope level but got %d", len(lv))
+	}
+	n, ok := lv[0].(float64)
+	if !ok || n < 0 || n != float64(int(n)) {
+		return 0, fmt.Errorf("expect non-negative integer scope level but got %v", lv[0])
+	}
+	return int(n), nil
+}
@@ -0,0 +1,108 @@
+package jsonlogic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpLetVal(t *testing.T) {
+	assert := assert.New(t)
+	jl := NewEmpty()
+	AddOpVar(jl)
+	AddOpAdd(jl)
+	AddOpMul(jl)
+	AddOpGreaterThan(jl)
+	AddOpMap(jl)
+	AddOpFilter(jl)
+	AddOpReduce(jl)
+	AddOpLet(jl)
+	AddOpVal(jl)
+	TestCases{
+		// Val without bindings.
+		{Logic: `{"val":[]}`, Data: `{"a":1}`, Result: map[string]interface{}{"a": float64(1)}},
+		{Logic: `{"val":null}`, Data: `{"a":1}`, Result: map[string]interface{}{"a": float64(1)}},
+		{Logic: `{"val":"a"}`, Data: `{"a":1}`, Result: float64(1)},
+		{Logic: `{"val":"a.b"}`, Data: `{"a.b":1,"a":{"b":2}}`, Result: float64(1)},
+		{Logic: `{"val":["a","b"]}`, Data: `{"a.b":1,"a":{"b":2}}`, Result: float64(2)},
+		{Logic: `{"val":["a",1]}`, Data: `{"a":[3,4]}`, Result: float64(4)},
+		{Logic: `{"val":["a","1"]}`, Data: `{"a":[3,4]}`, Result: float64(4)},
+		{Logic: `{"val":["a",2]}`, Data: `{"a":[3,4]}`, Result: nil},
+		{Logic: `{"val":["a",0.5]}`, Data: `{"a":[3,4]}`, Result: nil},
+		{Logic: `{"val":"x"}`, Data: `{"a":1}`, Result: nil},
+		{Logic: `{"val":[1]}`, Data: `{"1":"one"}`, Result: "one"},
+		{Logic: `{"val":[{"var":"k"}]}`, Data: `{"k":"a","a":"b"}`, Result: "b"},
+		// Let.
+		{Logic: `{"let":[{"total":{"+":[{"var":"a"},{"var":"b"}]}},{"*":[{"val":"total"},{"val":"total"}]}]}`, Data: `{"a":1,"b":2}`, Result: float64(9)},
+		{Logic: `{"let":[{"x":1,"y":2},{"+":[{"val":"x"},{"val":"y"}]}]}`, Data: `null`, Result: float64(3)},
+		{Logic: `{"let":[{"o":{"var":"obj"}},{"val":["o","k"]}]}`, Data: `{"obj":{"k":"v"}}`, Result: "v"},
+		{Logic: `{"let":[{},{"var":"a"}]}`, Data: `{"a":1}`, Result: float64(1)},
+		// Bindings shadow data.
+		{Logic: `{"let":[{"a":2},{"val":"a"}]}`, Data: `{"a":1}`, Result: float64(2)},
+		{Logic: `{"let":[{"a":2},{"var":"a"}]}`, Data: `{"a":1}`, Result: float64(1)},
+		// Inner bindings shadow outer ones.
+		{Logic: `{"let":[{"a":1},{"let":[{"a":{"+":[{"val":"a"},1]}},{"val":"a"}]}]}`, Data: `null`, Result: float64(2)},
+		{Logic: `{"let":[{"a":1},{"let":[{"b":{"+":[{"val":"a"},1]}},{"+":[{"val":"a"},{"val":"b"}]}]}]}`, Data: `null`, Result: float64(3)},
+		// Bindings are out of scope after let.
+		{Logic: `[{"let":[{"a":2},{"val":"a"}]},{"val":"a"}]`, Data: `{"a":1}`, Result: []interface{}{float64(2), float64(1)}},
+		// Bindings are reachable inside map/filter/reduce.
+		{Logic: `{"let":[{"limit":{"var":"limit"}},{"filter":[{"var":"amounts"},{">":[{"var":""},{"val":"limit"}]}]}]}`, Data: `{"limit":2,"amounts":[1,2,3,4]}`, Result: []interface{}{float64(3), float64(4)}},
+		{Logic: `{"let":[{"root":{"var":""}},{"map":[{"var":"items"},{"*":[{"var":"n"},{"val":["root","factor"]}]}]}]}`, Data: `{"factor":10,"items":[{"n":1},{"n":2}]}`, Result: []interface{}{float64(10), float64(20)}},
+		{Logic: `{"let":[{"w":2},{"reduce":[{"var":"xs"},{"+":[{"var":"accumulator"},{"*":[{"var":"current"},{"val":"w"}]}]},0]}]}`, Data: `{"xs":[1,2,3]}`, Result: float64(12)},
+		// Err.
+		{Logic: `{"let":[]}`, Data: `null`, Err: true},
+		{Logic: `{"let":[{"a":1}]}`, Data: `null`, Err: true},
+		{Logic: `{"let":[[],1]}`, Data: `null`, Err: true},
+		{Logic: `{"let":[{"":1},1]}`, Data: `null`, Err: true},
+		{Logic: `{"let":[{"a":{"xxx":1}},1]}`, Data: `null`, Err: true},
+		{Logic: `{"val":[[]]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
+
+func TestOpValLevelIndex(t *testing.T) {
+	assert := assert.New(t)
+	jl := NewEmpty()
+	AddOpVar(jl)
+	AddOpAdd(jl)
+	AddOpGreaterThan(jl)
+	AddOpCat(jl)
+	AddOpMap(jl)
+	AddOpFilter(jl)
+	AddOpReduce(jl)
+	AddOpAll(jl)
+	AddOpSome(jl)
+	AddOpNone(jl)
+	AddOpLet(jl)
+	AddOpVal(jl)
+	AddOpIndex(jl)
+	TestCases{
+		// Level 0 is current data.
+		{Logic: `{"val":[[0],"a"]}`, Data: `{"a":1}`, Result: float64(1)},
+		{Logic: `{"map":[[1,2],{"val":[[0]]}]}`, Data: `null`, Result: []interface{}{float64(1), float64(2)}},
+		// Outer data.
+		{Logic: `{"filter":[{"var":"orders"},{">":[{"val":"amount"},{"val":[[1],"limit"]}]}]}`, Data: `{"limit":10,"orders":[{"amount":5},{"amount":15}]}`, Result: []interface{}{map[string]interface{}{"amount": float64(15)}}},
+		{Logic: `{"map":[{"var":"xs"},{"+":[{"var":""},{"val":[[1],"base"]}]}]}`, Data: `{"base":100,"xs":[1,2]}`, Result: []interface{}{float64(101), float64(102)}},
+		{Logic: `{"map":[{"var":"xs"},{"map":[{"var":"ys"},{"cat":[{"val":[[1],"name"]},{"var":""},{"val":[[2],"sep"]}]}]}]}`, Data: `{"sep":";","xs":[{"name":"a","ys":[1,2]}]}`, Result: []interface{}{[]interface{}{"a1;", "a2;"}}},
+		{Logic: `{"all":[{"var":"xs"},{">":[{"var":""},{"val":[[1],"min"]}]}]}`, Data: `{"min":0,"xs":[1,2]}`, Result: true},
+		{Logic: `{"some":[{"var":"xs"},{">":[{"var":""},{"val":[[1],"min"]}]}]}`, Data: `{"min":1,"xs":[1,2]}`, Result: true},
+		{Logic: `{"none":[{"var":"xs"},{">":[{"var":""},{"val":[[1],"min"]}]}]}`, Data: `{"min":2,"xs":[1,2]}`, Result: true},
+		{Logic: `{"reduce":[{"var":"xs"},{"+":[{"var":"accumulator"},{"val":[[1],"w"]}]},0]}`, Data: `{"w":2,"xs":[1,2,3]}`, Result: float64(6)},
+		// "let" does not introduce a data level.
+		{Logic: `{"map":[[1],{"let":[{"x":1},{"val":[[1],"a"]}]}]}`, Data: `{"a":"A"}`, Result: []interface{}{"A"}},
+		// Level form does not look up bindings.
+		{Logic: `{"let":[{"a":2},{"val":[[0],"a"]}]}`, Data: `{"a":1}`, Result: float64(1)},
+		// Index.
+		{Logic: `{"map":[["a","b"],{"cat":[{"index":[]},":",{"var":""}]}]}`, Data: `null`, Result: []interface{}{"0:a", "1:b"}},
+		{Logic: `{"map":[[[1,2],[3]],{"map":[{"var":""},{"cat":[{"index":1},{"index":0}]}]}]}`, Data: `null`, Result: []interface{}{[]interface{}{"00", "01"}, []interface{}{"10"}}},
+		{Logic: `{"filter":[[5,6,7],{">":[{"index":null},0]}]}`, Data: `null`, Result: []interface{}{float64(6), float64(7)}},
+		{Logic: `{"reduce":[[5,6,7],{"+":[{"var":"accumulator"},{"index":[]}]},0]}`, Data: `null`, Result: float64(3)},
+		// Err.
+		{Logic: `{"val":[[1],"a"]}`, Data: `{"a":1}`, Err: true},
+		{Logic: `{"val":[[-1],"a"]}`, Data: `{"a":1}`, Err: true},
+		{Logic: `{"val":[[0.5],"a"]}`, Data: `{"a":1}`, Err: true},
+		{Logic: `{"val":[[1,2],"a"]}`, Data: `{"a":1}`, Err: true},
+		{Logic: `{"index":[]}`, Data: `null`, Err: true},
+		{Logic: `{"map":[[1],{"index":1}]}`, Data: `null`, Err: true},
+		{Logic: `{"map":[[1],{"index":"x"}]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
@@ -0,0 +1,423 @@
+// Package store is a store of named json logic rules loaded from a directory, with version history and
+// polling based hot reload.
+//
+// Each file in the directory is a rule: the file name without extension is the rule name and the content
+// is the logic in json (".json") or YAML (".yaml"/".yml"). Other files, hidden files and sub directories are
+// ignored. For example, a directory with "discount.json" and "eligibility.yaml" has rules "discount" and
+// "eligibility".
+//
+// All rules in the directory are loaded and validated together, a successful load becomes a new version
+// (snapshot) if any file changed. Versions are immutable and swapped atomically, so callers always see
+// a consistent set of rules. If any file is invalid, the whole load fails and the current version is kept.
+// Update files atomically (e.g. write to a hidden file then rename it) to avoid loading partially written files.
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/huangjunwen/jsonlogic-go"
+	"github.com/huangjunwen/jsonlogic-go/internal/yamljson"
+)
+
+// DefaultMaxHistory is the default max number of versions kept in history.
+const DefaultMaxHistory = 10
+
+var (
+	// ErrRuleNotFound is returned (wrapped) when a rule is not found.
+	ErrRuleNotFound = errors.New("rule not found")
+
+	// ErrVersionNotFound is returned (wrapped) when a version is not in history.
+	ErrVersionNotFound = errors.New("version not found")
+)
+
+// Options are options of Open.
+type Options struct {
+	// JSONLogic validates and evaluates rules, jsonlogic.DefaultJSONLogic if nil.
+	JSONLogic *jsonlogic.JSONLogic
+
+	// MaxHistory is the max number of latest versions kept in history, DefaultMaxHistory if not positive.
+	// The current version is always kept in history even if it is older.
+	MaxHistory int
+
+	// PollInterval is the interval to check the directory for changes, 0 to disable polling (use
+	// Store.Reload to reload manually).
+	PollInterval time.Duration
+
+	// OnReload is called (in the polling goroutine or the caller of Reload) after a new version is loaded.
+	OnReload func(*Snapshot)
+
+	// OnError is called in the polling goroutine when reloading fails. The same error is reported once
+	// until files change.
+	OnError func(error)
+}
+
+// Rule is a named rule.
+type Rule struct {
+	Name  string
+	Logic interface{}
+	// File is the file name in the directory.
+	File string
+	// Hash is the hex SHA-256 of the file content.
+	Hash string
+}
+
+// Snapshot is a version of all rules. It must not be modified.
+type Snapshot struct {
+	// Version starts from 1 and increases by 1 for each successful load with changes.
+	Version  int
+	LoadedAt time.Time
+	Rules    map[string]*Rule
+}
+
+// Rule returns the named rule.
+func (snap *Snapshot) Rule(name string) (*Rule, bool) {
+	r, ok := snap.Rules[name]
+	return r, ok
+}
+
+// Names returns sorted names of rules.
+func (snap *Snapshot) Names() []string {
+	ret := make([]string, 0, len(snap.Rules))
+	for name := range snap.Rules {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Store is a store of rules loaded from a directory. It is safe for concurrent use.
+type Store struct {
+	dir  string
+	jl   *jsonlogic.JSONLogic
+	opts Options
+
+	// reloadMu serializes reloads.
+	reloadMu   sync.Mutex
+	lastHash   string
+	failedHash string
+	lastErr    error
+
+	mu       sync.RWMutex
+	history  []*Snapshot
+	current  *Snapshot
+	pinned   bool
+	versions int
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Open loads rules from dir and starts polling if opts.PollInterval is positive. opts can be nil for
+// default options. It is an error if the initial load fails.
+func Open(dir string, opts *Options) (*Store, error) {
+	s := &Store{
+		dir:  dir,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	s.jl = s.opts.JSONLogic
+	if s.jl == nil {
+		s.jl = jsonlogic.DefaultJSONLogic
+	}
+	if s.opts.MaxHistory <= 0 {
+		s.opts.MaxHistory = DefaultMaxHistory
+	}
+
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	if s.opts.PollInterval > 0 {
+		go s.poll()
+	} else {
+		close(s.done)
+	}
+	return s, nil
+}
+
+func (s *Store) poll() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+	// The message of the last reported error.
+	reported := ""
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			_, err := s.Reload()
+			if err == nil {
+				reported = ""
+				continue
+			}
+			if err.Error() != reported && s.opts.OnError != nil {
+				s.opts.OnError(err)
+			}
+			reported = err.Error()
+		}
+	}
+}
+
+// Close stops polling.
+func (s *Store) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+// Reload loads rules from the directory, and returns true if a new version is created (files changed
+// since the last successful load). The new version becomes current unless a version is pinned.
+// On error, the current version is kept.
+func (s *Store) Reload() (bool, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	files, hash, err := readDir(s.dir)
+	if err != nil {
+		s.lastErr = err
+		return false, err
+	}
+	if hash == s.lastHash {
+		s.lastErr = nil
+		return false, nil
+	}
+	if hash == s.failedHash {
+		return false, s.lastErr
+	}
+
+	rules, err := s.parse(files)
+	if err != nil {
+		s.failedHash = hash
+		s.lastErr = err
+		return false, err
+	}
+	s.lastHash = hash
+	s.failedHash = ""
+	s.lastErr = nil
+
+	s.mu.Lock()
+	s.versions++
+	snap := &Snapshot{
+		Version:  s.versions,
+		LoadedAt: time.Now(),
+		Rules:    rules,
+	}
+	s.history = append(s.history, snap)
+	if !s.pinned {
+		s.current = snap
+	}
+	if len(s.history) > s.opts.MaxHistory {
+		keep := s.history[len(s.history)-s.opts.MaxHistory:]
+		history := make([]*Snapshot, 0, len(keep)+1)
+		// Keep the current version (pinned or rolled back) even if it is older.
+		if s.current.Version < keep[0].Version {
+			history = append(history, s.current)
+		}
+		s.history = append(history, keep...)
+	}
+	s.mu.Unlock()
+
+	if s.opts.OnReload != nil {
+		s.opts.OnReload(snap)
+	}
+	return true, nil
+}
+
+// LastError returns the error of the last reload, nil if it succeeded.
+func (s *Store) LastError() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	return s.lastErr
+}
+
+// ruleFile is a rule file read from the directory.
+type ruleFile struct {
+	name    string
+	file    string
+	ext     string
+	content []byte
+}
+
+// readDir reads rule files in dir (sorted by names) and returns them with a hash of all of them.
+func readDir(dir string) ([]ruleFile, string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("store: %s", err.Error())
+	}
+	var files []ruleFile
+	seen := map[string]string{}
+	h := sha256.New()
+	for _, info := range infos {
+		file := info.Name()
+		ext := strings.ToLower(filepath.Ext(file))
+		if info.IsDir() || strings.HasPrefix(file, ".") || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		name := strings.TrimSuffix(file, filepath.Ext(file))
+		if other, ok := seen[name]; ok {
+			return nil, "", fmt.Errorf("store: rule %q is defined in both %q and %q", name, other, file)
+		}
+		seen[name] = file
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return nil, "", fmt.Errorf("store: %s", err.Error())
+		}
+		files = append(files, ruleFile{
+			name:    name,
+			file:    file,
+			ext:     ext,
+			content: content,
+		})
+		fmt.Fprintf(h, "%s\x00%d\x00", file, len(content))
+		h.Write(content)
+	}
+	return files, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parse parses and validates rule files.
+func (s *Store) parse(files []ruleFile) (map[string]*Rule, error) {
+	rules := make(map[string]*Rule, len(files))
+	for _, f := range files {
+		var (
+			logic interface{}
+			err   error
+		)
+		if f.ext == ".json" {
+			logic, err = parseJSON(f.content)
+		} else {
+			logic, err = yamljson.Unmarshal(f.content)
+		}
+		if err == nil {
+			err = s.jl.Validate(logic)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("store: file %q: %s", f.file, err.Error())
+		}
+		sum := sha256.Sum256(f.content)
+		rules[f.name] = &Rule{
+			Name:  f.name,
+			Logic: logic,
+			File:  f.file,
+			Hash:  hex.EncodeToString(sum[:]),
+		}
+	}
+	return rules, nil
+}
+
+func parseJSON(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	var ret interface{}
+	if err := dec.Decode(&ret); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after logic")
+	}
+	return ret, nil
+}
+
+// Current returns the current version.
+func (s *Store) Current() *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Latest returns the latest loaded version, which is the current one unless pinned or rolled back.
+func (s *Store) Latest() *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.history[len(s.history)-1]
+}
+
+// History returns versions in history, oldest first.
+func (s *Store) History() []*Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*Snapshot(nil), s.history...)
+}
+
+// Version returns the version in history.
+func (s *Store) Version(version int) (*Snapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version(version)
+}
+
+func (s *Store) version(version int) (*Snapshot, bool) {
+	for _, snap := range s.history {
+		if snap.Version == version {
+			return snap, true
+		}
+	}
+	return nil, false
+}
+
+// Pin makes the version in history current, and keeps it current when new versions are loaded until Unpin.
+func (s *Store) Pin(version int) error {
+	return s.setCurrent(version, true)
+}
+
+// Rollback makes the version in history current. Unlike Pin, the next new version (when files change)
+// becomes current. It also unpins.
+func (s *Store) Rollback(version int) error {
+	return s.setCurrent(version, false)
+}
+
+func (s *Store) setCurrent(version int, pinned bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, ok := s.version(version)
+	if !ok {
+		return fmt.Errorf("store: %w: %d", ErrVersionNotFound, version)
+	}
+	s.current = snap
+	s.pinned = pinned
+	return nil
+}
+
+// Unpin makes the latest version current and follows new versions again.
+func (s *Store) Unpin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pinned = false
+	s.current = s.history[len(s.history)-1]
+}
+
+// Pinned returns true if a version is pinned.
+func (s *Store) Pinned() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pinned
+}
+
+// Get returns the named rule of the current version.
+func (s *Store) Get(name string) (*Rule, bool) {
+	return s.Current().Rule(name)
+}
+
+// Apply evaluates the named rule of the current version against data.
+func (s *Store) Apply(name string, data interface{}) (interface{}, error) {
+	r, ok := s.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("store: %w: %q", ErrRuleNotFound, name)
+	}
+	return s.jl.Apply(r.Logic, data)
+}
@@ -0,0 +1,335 @@
+package store
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huangjunwen/jsonlogic-go"
+	"github.com/huangjunwen/jsonlogic-go/ext"
+)
+
+// writeFiles writes files atomically.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		tmp := filepath.Join(dir, ".tmp")
+		assert.NoError(t, ioutil.WriteFile(tmp, []byte(content), 0644))
+		assert.NoError(t, os.Rename(tmp, filepath.Join(dir, name)))
+	}
+}
+
+func removeFiles(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		err := os.Remove(filepath.Join(dir, name))
+		if !os.IsNotExist(err) {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	return dir
+}
+
+func TestStore(t *testing.T) {
+	assert := assert.New(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	writeFiles(t, dir, map[string]string{
+		"discount.json":    `{"if":[{">=":[{"var":"total"},100]},0.1,0]}`,
+		"eligibility.yaml": "in: [{var: country}, [US, CA]]\n",
+		"adult.YML":        "'>=': [{var: age}, 18]\n",
+		"README.md":        "not a rule",
+		".hidden.json":     "{",
+	})
+	assert.NoError(os.Mkdir(filepath.Join(dir, "sub.json"), 0755))
+
+	var reloaded []int
+	s, err := Open(dir, &Options{
+		JSONLogic:  jsonlogic.New(),
+		MaxHistory: 3,
+		OnReload: func(snap *Snapshot) {
+			reloaded = append(reloaded, snap.Version)
+		},
+	})
+	assert.NoError(err)
+	defer s.Close()
+
+	assert.Equal([]int{1}, reloaded)
+	assert.Equal(1, s.Current().Version)
+	assert.Equal([]string{"adult", "discount", "eligibility"}, s.Current().Names())
+	r, ok := s.Get("eligibility")
+	assert.True(ok)
+	assert.Equal("eligibility.yaml", r.File)
+	assert.Len(r.Hash, 64)
+
+	res, err := s.Apply("discount", map[string]interface{}{"total": 120.0})
+	assert.NoError(err)
+	assert.Equal(0.1, res)
+	res, err = s.Apply("eligibility", map[string]interface{}{"country": "CA"})
+	assert.NoError(err)
+	assert.Equal(true, res)
+	res, err = s.Apply("adult", map[string]interface{}{"age": 17.0})
+	assert.NoError(err)
+	assert.Equal(false, res)
+	_, err = s.Apply("missing", nil)
+	assert.True(errors.Is(err, ErrRuleNotFound))
+
+	// No change.
+	changed, err := s.Reload()
+	assert.NoError(err)
+	assert.False(changed)
+
+	// Change.
+	writeFiles(t, dir, map[string]string{"discount.json": `{"if":[{">=":[{"var":"total"},100]},0.2,0]}`})
+	changed, err = s.Reload()
+	assert.NoError(err)
+	assert.True(changed)
+	assert.Equal(2, s.Current().Version)
+	res, err = s.Apply("discount", map[string]interface{}{"total": 120.0})
+	assert.NoError(err)
+	assert.Equal(0.2, res)
+
+	// Invalid changes are rejected as a whole, the current version is kept.
+	for _, files := range []map[string]string{
+		{"discount.json": `{"if":[`, "new.json": `1`},
+		{"discount.json": `{"unknown_op":1}`},
+		{"discount.json": `1 2`},
+		{"discount.json": ``},
+		{"eligibility.json": `true`},
+		{"eligibility.yaml": "{1: 2}\n"},
+	} {
+		writeFiles(t, dir, files)
+		changed, err = s.Reload()
+		assert.Error(err, "%v", files)
+		assert.False(changed)
+		assert.Error(s.LastError())
+		assert.Equal(2, s.Current().Version)
+		_, ok := s.Get("new")
+		assert.False(ok)
+		removeFiles(t, dir, "new.json", "eligibility.json")
+		writeFiles(t, dir, map[string]string{"eligibility.yaml": "in: [{var: country}, [US, CA]]\n"})
+	}
+
+	// Fixed: back to the same content of version 2, no new version.
+	writeFiles(t, dir, map[string]string{"discount.json": `{"if":[{">=":[{"var":"total"},100]},0.2,0]}`})
+	changed, err = s.Reload()
+	assert.NoError(err)
+	assert.False(changed)
+	assert.NoError(s.LastError())
+
+	// Removing a rule is a change.
+	removeFiles(t, dir, "adult.YML")
+	changed, err = s.Reload()
+	assert.NoError(err)
+	assert.True(changed)
+	assert.Equal(3, s.Current().Version)
+	_, ok = s.Get("adult")
+	assert.False(ok)
+
+	// Pin.
+	assert.NoError(s.Pin(2))
+	assert.True(s.Pinned())
+	assert.Equal(2, s.Current().Version)
+	writeFiles(t, dir, map[string]string{"discount.json": `0.3`})
+	changed, err = s.Reload()
+	assert.NoError(err)
+	assert.True(changed)
+	assert.Equal(2, s.Current().Version)
+	assert.Equal(4, s.Latest().Version)
+	s.Unpin()
+	assert.False(s.Pinned())
+	assert.Equal(4, s.Current().Version)
+
+	// History is bounded.
+	var versions []int
+	for _, snap := range s.History() {
+		versions = append(versions, snap.Version)
+	}
+	assert.Equal([]int{2, 3, 4}, versions)
+	_, ok = s.Version(1)
+	assert.False(ok)
+	assert.True(errors.Is(s.Pin(1), ErrVersionNotFound))
+	assert.True(errors.Is(s.Rollback(5), ErrVersionNotFound))
+
+	// Rollback follows new versions.
+	assert.NoError(s.Rollback(3))
+	assert.False(s.Pinned())
+	assert.Equal(3, s.Current().Version)
+	writeFiles(t, dir, map[string]string{"discount.json": `0.4`})
+	_, err = s.Reload()
+	assert.NoError(err)
+	assert.Equal(5, s.Current().Version)
+	res, err = s.Apply("discount", nil)
+	assert.NoError(err)
+	assert.Equal(0.4, res)
+
+	assert.Equal([]int{1, 2, 3, 4, 5}, reloaded)
+}
+
+func TestHistoryKeepsCurrent(t *testing.T) {
+	assert := assert.New(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	versions := func(s *Store) []int {
+		var ret []int
+		for _, snap := range s.History() {
+			ret = append(ret, snap.Version)
+		}
+		return ret
+	}
+	reload := func(s *Store, content string) {
+		writeFiles(t, dir, map[string]string{"a.json": content})
+		changed, err := s.Reload()
+		assert.NoError(err)
+		assert.True(changed)
+	}
+
+	writeFiles(t, dir, map[string]string{"a.json": `1`})
+	s, err := Open(dir, &Options{MaxHistory: 2})
+	assert.NoError(err)
+	defer s.Close()
+
+	assert.NoError(s.Pin(1))
+	reload(s, `2`)
+	reload(s, `3`)
+	assert.Equal([]int{1, 2, 3}, versions(s))
+	assert.Equal(1, s.Current().Version)
+	_, ok := s.Version(s.Current().Version)
+	assert.True(ok)
+	assert.NoError(s.Pin(1))
+	reload(s, `4`)
+	assert.Equal([]int{1, 3, 4}, versions(s))
+
+	// After rollback, the next version becomes current and old versions are trimmed.
+	assert.NoError(s.Rollback(3))
+	assert.Equal([]int{1, 3, 4}, versions(s))
+	reload(s, `5`)
+	assert.Equal([]int{4, 5}, versions(s))
+	assert.Equal(5, s.Current().Version)
+
+	s.Unpin()
+	reload(s, `6`)
+	assert.Equal([]int{5, 6}, versions(s))
+}
+
+func TestOpenError(t *testing.T) {
+	assert := assert.New(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	_, err := Open(filepath.Join(dir, "missing"), nil)
+	assert.Error(err)
+
+	writeFiles(t, dir, map[string]string{"a.json": `{"var":"a"}`, "a.yaml": `{var: a}`})
+	_, err = Open(dir, nil)
+	assert.Error(err)
+
+	removeFiles(t, dir, "a.yaml")
+	s, err := Open(dir, nil)
+	assert.NoError(err)
+	s.Close()
+	s.Close()
+}
+
+func TestRulesWithNonLogicParams(t *testing.T) {
+	assert := assert.New(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	jl := jsonlogic.New()
+	jsonlogic.AddOpLet(jl)
+	jsonlogic.AddOpVal(jl)
+	jsonlogic.AddOpDef(jl)
+	jsonlogic.AddOpCall(jl)
+	ext.AddOpPreserve(jl)
+	writeFiles(t, dir, map[string]string{
+		"let.json":      `{"let":[{"total":{"+":[{"var":"a"},{"var":"b"}]}},{"*":[{"val":"total"},2]}]}`,
+		"def.yaml":      "def:\n  - f: {body: 1}\n    g: {params: [x], body: {'+': [{val: x}, {call: f}]}}\n  - call: [g, {var: a}]\n",
+		"preserve.json": `{"preserve":{"a":1}}`,
+	})
+	s, err := Open(dir, &Options{JSONLogic: jl})
+	if !assert.NoError(err) {
+		return
+	}
+	defer s.Close()
+
+	data := map[string]interface{}{"a": 1.0, "b": 2.0}
+	for name, expect := range map[string]interface{}{
+		"let":      6.0,
+		"def":      2.0,
+		"preserve": map[string]interface{}{"a": 1.0},
+	} {
+		res, err := s.Apply(name, data)
+		assert.NoError(err, name)
+		assert.Equal(expect, res, name)
+	}
+
+	// Operators inside bindings are still checked.
+	writeFiles(t, dir, map[string]string{"let.json": `{"let":[{"total":{"unknown":1}},{"val":"total"}]}`})
+	_, err = s.Reload()
+	assert.Error(err)
+}
+
+func TestPoll(t *testing.T) {
+	assert := assert.New(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{"a.json": `1`})
+
+	var (
+		mu     sync.Mutex
+		errs   []error
+		loaded []int
+	)
+	s, err := Open(dir, &Options{
+		PollInterval: 10 * time.Millisecond,
+		OnReload: func(snap *Snapshot) {
+			mu.Lock()
+			defer mu.Unlock()
+			loaded = append(loaded, snap.Version)
+		},
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	})
+	assert.NoError(err)
+	defer s.Close()
+
+	writeFiles(t, dir, map[string]string{"a.json": `2`})
+	assert.Eventually(func() bool {
+		return s.Current().Version == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	res, err := s.Apply("a", nil)
+	assert.NoError(err)
+	assert.Equal(2.0, res)
+
+	// Errors are reported once.
+	writeFiles(t, dir, map[string]string{"a.json": `{`})
+	assert.Eventually(func() bool {
+		return s.LastError() != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	writeFiles(t, dir, map[string]string{"a.json": `3`})
+	assert.Eventually(func() bool {
+		return s.Current().Version == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	s.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(errs, 1)
+	assert.Equal([]int{1, 2, 3}, loaded)
+}
@@ -0,0 +1,134 @@
+package jsonlogic
+
+import (
+	"fmt"
+)
+
+// AddOpSwitch adds "switch" operation to the JSONLogic instance. Param restriction:
+//   - Two to three params: the subject, an array of cases and an optional default.
+//   - Each case is a pair [label, result]. Labels are json primitives (not evaluated), or arrays of them to
+//     share a result. Labels must be unique in a "switch".
+//
+// The subject is evaluated once, then compared with labels in order by strict equality ("==="), and the result
+// of the first matching case is evaluated and returned. If no case matches, the default is evaluated and returned,
+// or null without default. Use {"throw":...} as default if a match is mandatory. For example:
+//   logic: {"switch":[{"var":"plan"},[["free",0],[["pro","team"],10]],{"throw":"unknown_plan"}]}
+//   data: {"plan":"team"}
+//   result will be 10
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpSwitch(jl *JSONLogic) {
+	jl.AddOperation("switch", opSwitch)
+}
+
+func opSwitch(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	cases, err := switchCases("switch", params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate all labels before evaluating anything.
+	labels := make([][]interface{}, 0, len(cases))
+	seen := map[interface{}]struct{}{}
+	for _, c := range cases {
+		ls, ok := c[0].([]interface{})
+		if !ok {
+			ls = []interface{}{c[0]}
+		}
+		for _, label := range ls {
+			if !IsPrimitive(label) {
+				return nil, fmt.Errorf("switch: expect json primitive as case label but got %T", label)
+			}
+			if _, ok := seen[label]; ok {
+				return nil, fmt.Errorf("switch: duplicated case label %#v", label)
+			}
+			seen[label] = struct{}{}
+		}
+		labels = append(labels, ls)
+	}
+
+	subject, err := apply(params[0], data)
+	if err != nil {
+		return nil, err
+	}
+	for i, ls := range labels {
+		for _, label := range ls {
+			if eq, _ := CompareValues(EQ, subject, label); eq {
+				return apply(cases[i][1], data)
+			}
+		}
+	}
+	if len(params) > 2 {
+		return apply(params[2], data)
+	}
+	return nil, nil
+}
+
+// AddOpCond adds "cond" operation to the JSONLogic instance. Param restriction:
+//   - Two to three params: the subject, an array of cases and an optional default.
+//   - Each case is a pair [predicate, result].
+//
+// It is the predicate variant of "switch" (named "cond" because "match" is used by the regex extension in ext
+// package): the subject is evaluated once, then predicates are evaluated in order against the subject as data
+// (so {"var":""} is the subject and {"val":[[1],...]} reaches the outer data), and the result of the first
+// truthy one is evaluated against the current data and returned. If none is truthy, the default is evaluated
+// and returned, or null without default. For example:
+//   logic: {"cond":[{"var":"order.total"},[[{">=":[{"var":""},100]},"gold"],[{">=":[{"var":""},50]},"silver"]],"none"]}
+//   data: {"order":{"total":70}}
+//   result will be "silver"
+//
+// NOTE: This is an extension, not supported by json-logic-js.
+func AddOpCond(jl *JSONLogic) {
+	jl.AddOperation("cond", opCond)
+}
+
+func opCond(apply Applier, params []interface{}, data interface{}) (res interface{}, err error) {
+	cases, err := switchCases("cond", params)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := apply(params[0], data)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cases {
+		r, err := apply(scopedLogic{
+			logic: c[0],
+			frame: &scope{
+				hasData: true,
+				data:    subject,
+			},
+		}, subject)
+		if err != nil {
+			return nil, err
+		}
+		if ToBool(r) {
+			return apply(c[1], data)
+		}
+	}
+	if len(params) > 2 {
+		return apply(params[2], data)
+	}
+	return nil, nil
+}
+
+// switchCases checks params of "switch"/"cond" and returns the cases.
+func switchCases(name string, params []interface{}) ([][]interface{}, error) {
+	if len(params) < 2 || len(params) > 3 {
+		return nil, fmt.Errorf("%s: expect 2 to 3 params", name)
+	}
+	arr, ok := params[1].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expect array of cases but got %T", name, params[1])
+	}
+	cases := make([][]interface{}, 0, len(arr))
+	for _, item := range arr {
+		c, ok := item.([]interface{})
+		if !ok || len(c) != 2 {
+			return nil, fmt.Errorf("%s: expect [case, result] pairs as cases but got %v", name, item)
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
@@ -0,0 +1,92 @@
+package jsonlogic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpSwitchCond(t *testing.T) {
+	assert := assert.New(t)
+	jl := New()
+	AddOpVal(jl)
+	AddOpThrow(jl)
+	AddOpSwitch(jl)
+	AddOpCond(jl)
+	TestCases{
+		// switch.
+		{Logic: `{"switch":[{"var":"plan"},[["free",0],[["pro","team"],10]]]}`, Data: `{"plan":"team"}`, Result: float64(10)},
+		{Logic: `{"switch":[{"var":"plan"},[["free",0],[["pro","team"],10]]]}`, Data: `{"plan":"free"}`, Result: float64(0)},
+		{Logic: `{"switch":[{"var":"plan"},[["free",0],[["pro","team"],10]]]}`, Data: `{"plan":"x"}`, Result: nil},
+		{Logic: `{"switch":[{"var":"plan"},[["free",0]],-1]}`, Data: `{}`, Result: float64(-1)},
+		{Logic: `{"switch":[{"var":"n"},[["1","string"],[1,"number"],[null,"null"]]]}`, Data: `{"n":1}`, Result: "number"},
+		{Logic: `{"switch":[{"var":"n"},[["1","string"],[1,"number"],[null,"null"]]]}`, Data: `{}`, Result: "null"},
+		{Logic: `{"switch":[{"var":"n"},[[1,{"var":"a"}]],{"var":"b"}]}`, Data: `{"n":1,"a":"A","b":"B"}`, Result: "A"},
+		{Logic: `{"switch":[{"var":"n"},[[1,{"var":"a"}]],{"var":"b"}]}`, Data: `{"n":2,"a":"A","b":"B"}`, Result: "B"},
+		{Logic: `{"switch":[{"var":"n"},[[1,"a"]],{"throw":"no_match"}]}`, Data: `{"n":2}`, Err: true},
+		{Logic: `{"switch":[{"var":"n"},[[1,{"throw":"x"}],[2,"b"]]]}`, Data: `{"n":2}`, Result: "b"},
+		{Logic: `{"switch":[{"var":"n"},[]]}`, Data: `{}`, Result: nil},
+		// Invalid cases are errors even if not reached.
+		{Logic: `{"switch":[1,[[1,"a"],[1,"b"]]]}`, Data: `null`, Err: true},
+		{Logic: `{"switch":[1,[[1,"a"],[[2,1],"b"]]]}`, Data: `null`, Err: true},
+		{Logic: `{"switch":[1,[[1,"a"],[{"var":"x"},"b"]]]}`, Data: `null`, Err: true},
+		{Logic: `{"switch":[1,[[1,"a"],[[[1]],"b"]]]}`, Data: `null`, Err: true},
+		{Logic: `{"switch":[1,[[1,"a","b"]]]}`, Data: `null`, Err: true},
+		{Logic: `{"switch":[1,{"var":"cases"}]}`, Data: `null`, Err: true},
+		{Logic: `{"switch":[1]}`, Data: `null`, Err: true},
+		// cond.
+		{Logic: `{"cond":[{"var":"order.total"},[[{">=":[{"var":""},100]},"gold"],[{">=":[{"var":""},50]},"silver"]],"none"]}`, Data: `{"order":{"total":70}}`, Result: "silver"},
+		{Logic: `{"cond":[{"var":"order.total"},[[{">=":[{"var":""},100]},"gold"],[{">=":[{"var":""},50]},"silver"]],"none"]}`, Data: `{"order":{"total":170}}`, Result: "gold"},
+		{Logic: `{"cond":[{"var":"order.total"},[[{">=":[{"var":""},100]},"gold"],[{">=":[{"var":""},50]},"silver"]],"none"]}`, Data: `{"order":{"total":7}}`, Result: "none"},
+		{Logic: `{"cond":[{"var":"order.total"},[[{">=":[{"var":""},{"val":[[1],"limit"]}]},{"var":"limit"}]]]}`, Data: `{"limit":5,"order":{"total":7}}`, Result: float64(5)},
+		{Logic: `{"cond":[{"var":"x"},[[{"in":[{"var":""},["a","b"]]},"ab"],[true,"other"]]]}`, Data: `{"x":"c"}`, Result: "other"},
+		{Logic: `{"cond":[1,[[false,"a"]]]}`, Data: `null`, Result: nil},
+		{Logic: `{"cond":[1,[["a"]]]}`, Data: `null`, Err: true},
+	}.Run(assert, jl)
+}
+
+func TestOpSwitchSubjectOnce(t *testing.T) {
+	assert := assert.New(t)
+	jl := New()
+	AddOpSwitch(jl)
+	AddOpCond(jl)
+	calls := 0
+	jl.AddOperation("subject", func(apply Applier, params []interface{}, data interface{}) (interface{}, error) {
+		calls++
+		return "c", nil
+	})
+
+	for _, logic := range []interface{}{
+		map[string]interface{}{"switch": []interface{}{
+			map[string]interface{}{"subject": nil},
+			[]interface{}{
+				[]interface{}{"a", float64(1)},
+				[]interface{}{"b", float64(2)},
+				[]interface{}{"c", float64(3)},
+			},
+		}},
+		map[string]interface{}{"cond": []interface{}{
+			map[string]interface{}{"subject": nil},
+			[]interface{}{
+				[]interface{}{map[string]interface{}{"===": []interface{}{map[string]interface{}{"var": ""}, "a"}}, float64(1)},
+				[]interface{}{map[string]interface{}{"===": []interface{}{map[string]interface{}{"var": ""}, "b"}}, float64(2)},
+				[]interface{}{map[string]interface{}{"===": []interface{}{map[string]interface{}{"var": ""}, "c"}}, float64(3)},
+			},
+		}},
+	} {
+		calls = 0
+		res, err := jl.Apply(logic, nil)
+		assert.NoError(err)
+		assert.Equal(float64(3), res)
+		assert.Equal(1, calls)
+	}
+
+	// Labels are validated before evaluating the subject.
+	calls = 1
+	_, err := jl.Apply(map[string]interface{}{"switch": []interface{}{
+		map[string]interface{}{"subject": nil},
+		[]interface{}{[]interface{}{"a", float64(1)}, []interface{}{"a", float64(2)}},
+	}}, nil)
+	assert.Error(err)
+	assert.Equal(1, calls)
+}